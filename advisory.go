@@ -0,0 +1,409 @@
+package dotnetexecute
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// AdvisoryDatabaseBindingType is the type of the service binding that
+// provides an advisory database in OSV JSON format.
+const AdvisoryDatabaseBindingType = "dotnet-advisory-db"
+
+// The severities of an advisory, in increasing order. Advisories that do not
+// state a severity have SeverityUnknown.
+const (
+	SeverityUnknown  = "UNKNOWN"
+	SeverityLow      = "LOW"
+	SeverityModerate = "MODERATE"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+var severityRanks = map[string]int{
+	SeverityUnknown:  0,
+	SeverityLow:      1,
+	SeverityModerate: 2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// osvAdvisory holds the parts of an OSV advisory, see
+// https://ossf.github.io/osv-schema/, needed to match NuGet packages.
+type osvAdvisory struct {
+	ID               string        `json:"id"`
+	Summary          string        `json:"summary"`
+	Aliases          []string      `json:"aliases"`
+	Affected         []osvAffected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges            []osvRange `json:"ranges"`
+	Versions          []string   `json:"versions"`
+	EcosystemSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"ecosystem_specific"`
+}
+
+type osvRange struct {
+	Type   string `json:"type"`
+	Events []struct {
+		Introduced   string `json:"introduced"`
+		Fixed        string `json:"fixed"`
+		LastAffected string `json:"last_affected"`
+	} `json:"events"`
+}
+
+// advisoryMatch is an advisory that affects a NuGet package of the app.
+type advisoryMatch struct {
+	Package  string
+	Version  string
+	ID       string
+	Severity string
+	Fixed    string
+}
+
+// parseSeverity returns the canonical name of the given severity threshold.
+func parseSeverity(value string) (string, error) {
+	severity := strings.ToUpper(value)
+	if severity == "MEDIUM" {
+		severity = SeverityModerate
+	}
+
+	if _, ok := severityRanks[severity]; !ok || severity == SeverityUnknown {
+		return "", fmt.Errorf("unsupported BP_DOTNET_FAIL_ON_SEVERITY value: %q, must be one of low, moderate, high or critical", value)
+	}
+
+	return severity, nil
+}
+
+// advisoryDatabasePaths returns the advisory database files given through
+// BP_DOTNET_ADVISORY_DB and through a dotnet-advisory-db service binding.
+// Service bindings of every type are loaded to find the latter, so when they
+// cannot be read, only the former is returned, along with a warning: a
+// malformed binding that has nothing to do with advisories must not fail the
+// build.
+func advisoryDatabasePaths(path, platformDir string) ([]string, string) {
+	var paths []string
+	if path != "" {
+		paths = append(paths, path)
+	}
+
+	bindings, err := servicebindings.NewResolver().Resolve(AdvisoryDatabaseBindingType, "", platformDir)
+	if err != nil {
+		return paths, fmt.Sprintf("failed to resolve %s binding: %s", AdvisoryDatabaseBindingType, err)
+	}
+
+	for _, binding := range bindings {
+		var names []string
+		for name := range binding.Entries {
+			if strings.HasSuffix(name, ".json") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			paths = append(paths, filepath.Join(binding.Path, name))
+		}
+	}
+
+	return paths, ""
+}
+
+// loadAdvisories reads the advisories from the given files. A file may hold a
+// single OSV advisory or an array of them, and a directory is read as the
+// *.json files it contains.
+func loadAdvisories(paths []string) ([]osvAdvisory, error) {
+	var advisories []osvAdvisory
+	for _, path := range paths {
+		files := []string{path}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load advisory database: %w", err)
+		}
+
+		if info.IsDir() {
+			files, err = filepath.Glob(filepath.Join(path, "*.json"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load advisory database: %w", err)
+			}
+		}
+
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load advisory database: %w", err)
+			}
+
+			content = bytes.TrimSpace(content)
+			if bytes.HasPrefix(content, []byte("[")) {
+				var list []osvAdvisory
+				err = json.Unmarshal(content, &list)
+				advisories = append(advisories, list...)
+			} else {
+				var advisory osvAdvisory
+				err = json.Unmarshal(content, &advisory)
+				advisories = append(advisories, advisory)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse advisory database %s: %w", file, err)
+			}
+		}
+	}
+
+	return advisories, nil
+}
+
+// appNuGetLibraries returns the NuGet packages and runtime packs recorded in
+// the *.deps.json files under the given directory. Packages shared by several
+// apps are only returned once.
+func appNuGetLibraries(root string) ([]DepsLibrary, error) {
+	depsFiles, err := findAppFiles(root, []string{root}, nil, ".deps.json")
+	if err != nil {
+		return nil, err
+	}
+
+	parser := NewDepsParser()
+
+	var libraries []DepsLibrary
+	seen := map[string]bool{}
+	for _, path := range depsFiles {
+		deps, err := parser.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, library := range deps.Libraries {
+			if library.Type != DepsLibraryTypePackage && library.Type != DepsLibraryTypeRuntimePack {
+				continue
+			}
+
+			// NuGet package IDs are case-insensitive
+			key := fmt.Sprintf("%s/%s", strings.ToLower(library.Name), library.Version)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			libraries = append(libraries, library)
+		}
+	}
+
+	return libraries, nil
+}
+
+// matchAdvisories returns the advisories that affect the given NuGet
+// packages, ordered by decreasing severity.
+func matchAdvisories(advisories []osvAdvisory, libraries []DepsLibrary) []advisoryMatch {
+	var matches []advisoryMatch
+	for _, library := range libraries {
+		for _, advisory := range advisories {
+			for _, affected := range advisory.Affected {
+				if !strings.EqualFold(affected.Package.Ecosystem, "NuGet") || !strings.EqualFold(affected.Package.Name, library.Name) {
+					continue
+				}
+
+				fixed, ok := affectedVersion(library.Version, affected)
+				if !ok {
+					continue
+				}
+
+				severity := strings.ToUpper(affected.EcosystemSpecific.Severity)
+				if severity == "" {
+					severity = strings.ToUpper(advisory.DatabaseSpecific.Severity)
+				}
+				if severity == "MEDIUM" {
+					severity = SeverityModerate
+				}
+				if _, ok := severityRanks[severity]; !ok {
+					severity = SeverityUnknown
+				}
+
+				matches = append(matches, advisoryMatch{
+					Package:  library.Name,
+					Version:  library.Version,
+					ID:       advisory.ID,
+					Severity: severity,
+					Fixed:    fixed,
+				})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return severityRanks[matches[i].Severity] > severityRanks[matches[j].Severity]
+	})
+
+	return matches
+}
+
+// affectedVersion returns whether the given version is affected according
+// to the explicit versions and ECOSYSTEM or SEMVER ranges of an OSV affected
+// entry, along with the version that fixes it, if any.
+func affectedVersion(version string, affected osvAffected) (string, bool) {
+	for _, v := range affected.Versions {
+		if compareNuGetVersions(v, version) == 0 {
+			return "", true
+		}
+	}
+
+	for _, r := range affected.Ranges {
+		if r.Type != "ECOSYSTEM" && r.Type != "SEMVER" {
+			continue
+		}
+
+		introduced := false
+		for _, event := range r.Events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || compareNuGetVersions(version, event.Introduced) >= 0 {
+					introduced = true
+				}
+			case event.Fixed != "":
+				if compareNuGetVersions(version, event.Fixed) >= 0 {
+					introduced = false
+				} else if introduced {
+					return event.Fixed, true
+				}
+			case event.LastAffected != "":
+				if compareNuGetVersions(version, event.LastAffected) > 0 {
+					introduced = false
+				} else if introduced {
+					return "", true
+				}
+			}
+		}
+
+		if introduced {
+			return "", true
+		}
+	}
+
+	return "", false
+}
+
+// compareNuGetVersions compares two NuGet versions, which have up to four
+// numeric parts and an optional pre-release label, ignoring build metadata.
+func compareNuGetVersions(a, b string) int {
+	aRelease, aLabel, _ := strings.Cut(strings.SplitN(a, "+", 2)[0], "-")
+	bRelease, bLabel, _ := strings.Cut(strings.SplitN(b, "+", 2)[0], "-")
+
+	aParts := strings.Split(aRelease, ".")
+	bParts := strings.Split(bRelease, ".")
+	for i := 0; i < 4; i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aPart != bPart {
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// A release sorts after its pre-releases
+	switch {
+	case aLabel == bLabel:
+		return 0
+	case aLabel == "":
+		return 1
+	case bLabel == "":
+		return -1
+	default:
+		return comparePreReleaseLabels(aLabel, bLabel)
+	}
+}
+
+// comparePreReleaseLabels compares two pre-release labels the way SemVer 2.0
+// does, which NuGet follows: identifier by identifier, numerically when both
+// are numeric, so that beta.2 sorts before beta.10. Numeric identifiers sort
+// before alphanumeric ones, which NuGet compares case-insensitively, and a
+// label sorts before the longer labels it is a prefix of.
+func comparePreReleaseLabels(a, b string) int {
+	aIdentifiers := strings.Split(a, ".")
+	bIdentifiers := strings.Split(b, ".")
+	for i := 0; i < len(aIdentifiers) && i < len(bIdentifiers); i++ {
+		aIdentifier, bIdentifier := aIdentifiers[i], bIdentifiers[i]
+		aNumeric, bNumeric := isNumericIdentifier(aIdentifier), isNumericIdentifier(bIdentifier)
+
+		var result int
+		switch {
+		case aNumeric && bNumeric:
+			// Comparing by length first avoids overflowing on long numbers
+			aIdentifier = strings.TrimLeft(aIdentifier, "0")
+			bIdentifier = strings.TrimLeft(bIdentifier, "0")
+			result = cmp.Or(cmp.Compare(len(aIdentifier), len(bIdentifier)), strings.Compare(aIdentifier, bIdentifier))
+		case aNumeric:
+			result = -1
+		case bNumeric:
+			result = 1
+		default:
+			result = strings.Compare(strings.ToLower(aIdentifier), strings.ToLower(bIdentifier))
+		}
+
+		if result != 0 {
+			return result
+		}
+	}
+
+	return cmp.Compare(len(aIdentifiers), len(bIdentifiers))
+}
+
+func isNumericIdentifier(identifier string) bool {
+	if identifier == "" {
+		return false
+	}
+
+	for _, r := range identifier {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// logAdvisoryMatches logs the matches as a table.
+func logAdvisoryMatches(logger scribe.Emitter, matches []advisoryMatch) {
+	buffer := bytes.NewBuffer(nil)
+	writer := tabwriter.NewWriter(buffer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "PACKAGE\tVERSION\tADVISORY\tSEVERITY\tFIXED IN")
+	for _, match := range matches {
+		fixed := match.Fixed
+		if fixed == "" {
+			fixed = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", match.Package, match.Version, match.ID, match.Severity, fixed)
+	}
+	writer.Flush()
+
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		logger.Subprocess("%s", line)
+	}
+}
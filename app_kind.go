@@ -0,0 +1,83 @@
+package dotnetexecute
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// AppKind is the way a .NET app is deployed, which determines what it needs
+// at launch time and how it is started.
+type AppKind string
+
+// The kinds of app the buildpack supports. See
+// https://learn.microsoft.com/en-us/dotnet/core/deploying/ for the published
+// ones.
+const (
+	// AppKindSource is an app given as source code, which is published into
+	// one of the other kinds during the build.
+	AppKindSource AppKind = "source"
+
+	// AppKindFrameworkDependentDeployment is an app published as a DLL that
+	// is run with the dotnet host from a shared framework.
+	AppKindFrameworkDependentDeployment AppKind = "framework-dependent-deployment"
+
+	// AppKindFrameworkDependentExecutable is an app published with an apphost
+	// executable that runs it on a shared framework.
+	AppKindFrameworkDependentExecutable AppKind = "framework-dependent-executable"
+
+	// AppKindSelfContainedExecutable is an app published with an apphost
+	// executable and the .NET runtime it runs on.
+	AppKindSelfContainedExecutable AppKind = "self-contained-executable"
+)
+
+// Inspector infers the AppKind of an app.
+type Inspector struct{}
+
+func NewInspector() Inspector {
+	return Inspector{}
+}
+
+// Inspect returns the AppKind of the app described by the given runtime
+// configuration, along with the evidence it was inferred from. An app
+// without a runtime configuration, i.e. one whose Path is empty, is a source
+// app when the given project file is set.
+func (i Inspector) Inspect(runtimeConfig RuntimeConfig, projectFile string) (AppKind, []string) {
+	if runtimeConfig.Path == "" {
+		if projectFile == "" {
+			return "", nil
+		}
+
+		return AppKindSource, []string{fmt.Sprintf("found project file '%s'", filepath.Base(projectFile))}
+	}
+
+	evidence := []string{fmt.Sprintf("found '%s'", filepath.Base(runtimeConfig.Path))}
+	if runtimeConfig.RuntimeVersion == "" {
+		evidence = append(evidence, "references no Microsoft.NETCore.App framework")
+	} else {
+		evidence = append(evidence, fmt.Sprintf("references Microsoft.NETCore.App framework version %s", runtimeConfig.RuntimeVersion))
+	}
+
+	if runtimeConfig.Executable {
+		evidence = append(evidence, fmt.Sprintf("found executable '%s'", runtimeConfig.AppName))
+	} else {
+		evidence = append(evidence, fmt.Sprintf("found no executable '%s'", runtimeConfig.AppName))
+	}
+
+	switch {
+	case runtimeConfig.RuntimeVersion == "":
+		return AppKindSelfContainedExecutable, evidence
+	case runtimeConfig.Executable:
+		return AppKindFrameworkDependentExecutable, evidence
+	default:
+		return AppKindFrameworkDependentDeployment, evidence
+	}
+}
+
+func logAppKind(logger scribe.Emitter, path string, kind AppKind, evidence []string) {
+	logger.Debug.Subprocess("Detected %s app '%s'", kind, path)
+	for _, line := range evidence {
+		logger.Debug.Action(line)
+	}
+}
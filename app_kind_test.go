@@ -0,0 +1,88 @@
+package dotnetexecute_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
+	"github.com/sclevine/spec"
+)
+
+func testInspector(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		inspector dotnetexecute.Inspector
+	)
+
+	it.Before(func() {
+		inspector = dotnetexecute.NewInspector()
+	})
+
+	context("Inspect", func() {
+		context("when there is only a project file", func() {
+			it("returns a source app", func() {
+				kind, evidence := inspector.Inspect(dotnetexecute.RuntimeConfig{}, "/workspace/MyApp.csproj")
+				Expect(kind).To(Equal(dotnetexecute.AppKindSource))
+				Expect(evidence).To(Equal([]string{"found project file 'MyApp.csproj'"}))
+			})
+		})
+
+		context("when the app references a framework and has no executable", func() {
+			it("returns a framework-dependent deployment", func() {
+				kind, evidence := inspector.Inspect(dotnetexecute.RuntimeConfig{
+					Path:           "/workspace/MyApp.runtimeconfig.json",
+					AppName:        "MyApp",
+					RuntimeVersion: "8.0.0",
+				}, "")
+				Expect(kind).To(Equal(dotnetexecute.AppKindFrameworkDependentDeployment))
+				Expect(evidence).To(Equal([]string{
+					"found 'MyApp.runtimeconfig.json'",
+					"references Microsoft.NETCore.App framework version 8.0.0",
+					"found no executable 'MyApp'",
+				}))
+			})
+		})
+
+		context("when the app references a framework and has an executable", func() {
+			it("returns a framework-dependent executable", func() {
+				kind, evidence := inspector.Inspect(dotnetexecute.RuntimeConfig{
+					Path:           "/workspace/MyApp.runtimeconfig.json",
+					AppName:        "MyApp",
+					RuntimeVersion: "8.0.0",
+					Executable:     true,
+				}, "/workspace/MyApp.csproj")
+				Expect(kind).To(Equal(dotnetexecute.AppKindFrameworkDependentExecutable))
+				Expect(evidence).To(Equal([]string{
+					"found 'MyApp.runtimeconfig.json'",
+					"references Microsoft.NETCore.App framework version 8.0.0",
+					"found executable 'MyApp'",
+				}))
+			})
+		})
+
+		context("when the app references no framework", func() {
+			it("returns a self-contained executable", func() {
+				kind, evidence := inspector.Inspect(dotnetexecute.RuntimeConfig{
+					Path:       "/workspace/MyApp.runtimeconfig.json",
+					AppName:    "MyApp",
+					Executable: true,
+				}, "")
+				Expect(kind).To(Equal(dotnetexecute.AppKindSelfContainedExecutable))
+				Expect(evidence).To(Equal([]string{
+					"found 'MyApp.runtimeconfig.json'",
+					"references no Microsoft.NETCore.App framework",
+					"found executable 'MyApp'",
+				}))
+			})
+		})
+
+		context("when there is neither a runtime configuration nor a project file", func() {
+			it("returns no kind", func() {
+				kind, evidence := inspector.Inspect(dotnetexecute.RuntimeConfig{}, "")
+				Expect(kind).To(BeEmpty())
+				Expect(evidence).To(BeEmpty())
+			})
+		})
+	})
+}
@@ -0,0 +1,182 @@
+package dotnetexecute
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// elfArchs maps ELF machine types to the GOARCH names of the architectures
+// .NET supports on Linux.
+var elfArchs = map[elf.Machine]string{
+	elf.EM_X86_64:    "amd64",
+	elf.EM_AARCH64:   "arm64",
+	elf.EM_ARM:       "arm",
+	elf.EM_386:       "386",
+	elf.EM_RISCV:     "riscv64",
+	elf.EM_PPC64:     "ppc64le",
+	elf.EM_S390:      "s390x",
+	elf.EM_LOONGARCH: "loong64",
+}
+
+// elfInfo describes a Linux ELF file.
+type elfInfo struct {
+	// Arch is the architecture of the file, using GOARCH names.
+	Arch string
+
+	// Executable is set for executables, as opposed to shared libraries.
+	Executable bool
+
+	// ImageSize is the size of the ELF image, i.e. of its headers, segments
+	// and sections. Single-file bundles append the files they embed past it.
+	ImageSize int64
+}
+
+// inspectELF reads the ELF header of the file at the given path, and reports
+// whether it is a Linux ELF file at all. Missing files are not.
+func inspectELF(path string) (elfInfo, bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return elfInfo{}, false, nil
+		}
+		return elfInfo{}, false, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return elfInfo{}, false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return elfInfo{}, false, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+	defer file.Close()
+
+	elfFile, err := elf.NewFile(file)
+	if err != nil {
+		var formatErr *elf.FormatError
+		if errors.As(err, &formatErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return elfInfo{}, false, nil
+		}
+		return elfInfo{}, false, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+
+	if elfFile.OSABI != elf.ELFOSABI_NONE && elfFile.OSABI != elf.ELFOSABI_LINUX {
+		return elfInfo{}, false, nil
+	}
+
+	arch, ok := elfArchs[elfFile.Machine]
+	if !ok {
+		arch = strings.ToLower(strings.TrimPrefix(elfFile.Machine.String(), "EM_"))
+	}
+
+	// Position-independent executables are shared objects that request a
+	// program interpreter, which plain shared libraries do not
+	executable := elfFile.Type == elf.ET_EXEC || (elfFile.Type == elf.ET_DYN && hasInterpreter(elfFile))
+
+	imageSize, err := elfImageSize(file, elfFile)
+	if err != nil {
+		return elfInfo{}, false, fmt.Errorf("failed to inspect %s: %w", path, err)
+	}
+
+	return elfInfo{Arch: arch, Executable: executable, ImageSize: imageSize}, true, nil
+}
+
+// elfImageSize returns the offset of the end of the furthest of the header
+// tables, segments and sections of the given ELF file.
+func elfImageSize(reader io.ReaderAt, file *elf.File) (int64, error) {
+	var size int64
+	extend := func(offset, length uint64) {
+		if end := int64(offset + length); end > size {
+			size = end
+		}
+	}
+
+	section := io.NewSectionReader(reader, 0, 64)
+	switch file.Class {
+	case elf.ELFCLASS64:
+		var header elf.Header64
+		if err := binary.Read(section, file.ByteOrder, &header); err != nil {
+			return 0, err
+		}
+		extend(0, uint64(header.Ehsize))
+		extend(header.Phoff, uint64(header.Phnum)*uint64(header.Phentsize))
+		extend(header.Shoff, uint64(header.Shnum)*uint64(header.Shentsize))
+	case elf.ELFCLASS32:
+		var header elf.Header32
+		if err := binary.Read(section, file.ByteOrder, &header); err != nil {
+			return 0, err
+		}
+		extend(0, uint64(header.Ehsize))
+		extend(uint64(header.Phoff), uint64(header.Phnum)*uint64(header.Phentsize))
+		extend(uint64(header.Shoff), uint64(header.Shnum)*uint64(header.Shentsize))
+	}
+
+	for _, prog := range file.Progs {
+		extend(prog.Off, prog.Filesz)
+	}
+
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_NOBITS {
+			extend(section.Offset, section.FileSize)
+		}
+	}
+
+	return size, nil
+}
+
+// inspectAppHost reports whether the file at the given path is a Linux
+// apphost, i.e. an ELF executable that can be launched directly, along with
+// its architecture. Missing files and files that are not such an executable
+// are not an apphost.
+func inspectAppHost(path string) (string, bool, error) {
+	info, ok, err := inspectELF(path)
+	if err != nil || !ok || !info.Executable {
+		return "", false, err
+	}
+
+	return info.Arch, true, nil
+}
+
+func hasInterpreter(file *elf.File) bool {
+	for _, prog := range file.Progs {
+		if prog.Type == elf.PT_INTERP {
+			return true
+		}
+	}
+
+	return false
+}
+
+// restoreExecBit makes the executable at the given path, such as an apphost
+// or a migration bundle, executable again when it has lost its exec bit, and
+// reports whether it had to.
+//
+// Mode bits are often lost or wrongly set when apps are zipped or checked
+// into git, which is why executables are recognized by their ELF header
+// rather than their mode, and given their exec bit back with this helper.
+func restoreExecBit(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if info.Mode()&0111 != 0 {
+		return false, nil
+	}
+
+	err = os.Chmod(path, info.Mode()|0111)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore the exec bit of %s: %w", path, err)
+	}
+
+	return true, nil
+}
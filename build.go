@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Netflix/go-env"
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
@@ -18,7 +19,19 @@ import (
 
 //go:generate faux --interface SBOMGenerator --output fakes/sbom_generator.go
 type SBOMGenerator interface {
-	Generate(path string) (sbom.SBOM, error)
+	Generate(path string, scope SBOMScope) (sbom.SBOM, error)
+}
+
+// SBOMScope limits the files of the app an SBOMGenerator looks at. Paths and
+// patterns are relative to the app directory.
+type SBOMScope struct {
+	// Paths are the files and directories to include. The whole app directory
+	// is included when it is empty.
+	Paths []string
+
+	// Exclude are glob patterns, which may use **, of the files and
+	// directories to leave out. Excluding a directory excludes its contents.
+	Exclude []string
 }
 
 // Build will return a packit.BuildFunc that will be invoked during the build
@@ -27,6 +40,9 @@ type SBOMGenerator interface {
 // Build generates a SBOM of the .NET app's dependencies based on its compiled
 // DLLs. It sets up the entrypoint for the app image and adds a helper that
 // will determine at launch-time which container port the app should listen on.
+// Each *.runtimeconfig.json file in the app directory becomes its own process
+// type. See Configuration for details on how environment variable
+// configuration influences the build.
 func Build(
 	config Configuration,
 	configParser ConfigParser,
@@ -52,83 +68,393 @@ func Build(
 		}
 		logger.Debug.Break()
 
-		runtimeConfig, err := configParser.Parse(filepath.Join(context.WorkingDir, "*.runtimeconfig.json"))
+		launchArgs, err := parseShellWords(config.LaunchArgs)
+		if err != nil {
+			return packit.BuildResult{}, fmt.Errorf("failed to parse BP_DOTNET_LAUNCH_ARGS: %w", err)
+		}
+
+		var failOnSeverity string
+		if config.FailOnSeverity != "" {
+			failOnSeverity, err = parseSeverity(config.FailOnSeverity)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		appRoot := context.WorkingDir
+		if config.ProjectPath != "" {
+			appRoot = filepath.Join(context.WorkingDir, config.ProjectPath)
+		}
+
+		runtimeConfigs, err := configParser.ParseAll(filepath.Join(appRoot, "*.runtimeconfig.json"))
+		if errors.Is(err, os.ErrNotExist) && appRoot != context.WorkingDir {
+			// Source apps are published into the working directory rather than
+			// into the project directory
+			appRoot = context.WorkingDir
+			runtimeConfigs, err = configParser.ParseAll(filepath.Join(appRoot, "*.runtimeconfig.json"))
+		}
 		if err != nil {
 			return packit.BuildResult{}, fmt.Errorf("failed to find *.runtimeconfig.json: %w", err)
 		}
 
-		logger.GeneratingSBOM(context.WorkingDir)
-		var sbomContent sbom.SBOM
-		duration, err := clock.Measure(func() error {
-			sbomContent, err = sbomGenerator.Generate(context.WorkingDir)
-			return err
-		})
+		logger.Debug.Process("Using app root '%s'", appRoot)
+		logger.Debug.Break()
+
+		defaultApp, err := defaultAppName(config.DefaultProcess, runtimeConfigs)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		logger.Action("Completed in %s", duration.Round(time.Millisecond))
-		logger.Break()
+		inspector := NewInspector()
+		appKinds := map[string]AppKind{}
+		for _, runtimeConfig := range runtimeConfigs {
+			kind, evidence := inspector.Inspect(runtimeConfig, "")
+			appKinds[runtimeConfig.AppName] = kind
+
+			logger.Process("Detected %s app '%s'", kind, runtimeConfig.AppName)
+			for _, line := range evidence {
+				logger.Debug.Subprocess(line)
+			}
+
+			if runtimeConfig.Executable {
+				restored, err := restoreExecBit(strings.TrimSuffix(runtimeConfig.Path, ".runtimeconfig.json"))
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
 
-		logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
-		sbomFormatter, err := sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
+				if restored {
+					logger.Subprocess("Restored the exec bit of the '%s' apphost", runtimeConfig.AppName)
+				}
+			}
+			logger.Break()
+
+			logger.Debug.Process("Using runtime configuration '%s'", runtimeConfig.Path)
+			logConfigProperties(logger, runtimeConfig.ConfigProperties)
+			logger.Debug.Break()
+		}
+
+		arch := targetArch()
+		warning, err := checkAppArch(appRoot, runtimeConfigs, arch, muslStack(context.Stack))
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		command := filepath.Join(context.WorkingDir, runtimeConfig.AppName)
-		var args []string
-		if !runtimeConfig.Executable {
-			_, err := os.Stat(filepath.Join(context.WorkingDir, fmt.Sprintf("%s.dll", runtimeConfig.AppName)))
+		if warning != "" {
+			logger.Process("Warning: %s", warning)
+			logger.Break()
+		}
+
+		var frameworkVersions []string
+		for _, runtimeConfig := range runtimeConfigs {
+			frameworkVersion := runtimeConfig.RuntimeVersion
+			if frameworkVersion == "*" {
+				frameworkVersion = ""
+			}
+
+			deps, err := NewDepsParser().Parse(fmt.Sprintf("%s.deps.json", strings.TrimSuffix(runtimeConfig.Path, ".runtimeconfig.json")))
 			if err != nil && !errors.Is(err, os.ErrNotExist) {
 				return packit.BuildResult{}, err
 			}
-			if errors.Is(err, os.ErrNotExist) {
-				return packit.BuildResult{}, fmt.Errorf("no entrypoint [%s.dll] found: %w ", runtimeConfig.AppName, err)
+
+			if err == nil {
+				// Self-contained apps only record the framework they target in
+				// their deps.json file
+				if frameworkVersion == "" {
+					frameworkVersion = targetFrameworkVersion(deps.RuntimeTarget)
+				}
+
+				warning, err := checkRuntimeTarget(runtimeConfig.AppName, deps.RID, arch, muslStack(context.Stack))
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+
+				if warning != "" {
+					logger.Process("Warning: %s", warning)
+					logger.Break()
+				}
 			}
 
-			command = "dotnet"
-			args = append(args, fmt.Sprintf("%s.dll", filepath.Join(context.WorkingDir, runtimeConfig.AppName)))
+			frameworkVersions = append(frameworkVersions, frameworkVersion)
 		}
 
-		processes := []packit.Process{
-			{
-				Type:    runtimeConfig.AppName,
-				Command: command,
-				Args:    args,
-				Default: true,
-				Direct:  true,
-			},
+		// The port chooser sets the port for every app in the image, so it is
+		// given the version of the oldest one
+		frameworkVersion := lowestFrameworkVersion(frameworkVersions)
+
+		processWorkingDir := appRoot
+		if config.WorkingDirectory != "" {
+			processWorkingDir = config.WorkingDirectory
+			if !filepath.IsAbs(processWorkingDir) {
+				processWorkingDir = filepath.Join(context.WorkingDir, processWorkingDir)
+			}
+		}
+
+		sbomScope, err := parseSBOMScope(config.SBOMPaths, config.SBOMExclude)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		sbomCacheLayer, err := context.Layers.Get("sbom-cache")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// The buildpack version is part of the key, as upgrades may change
+		// what the generated SBOM contains
+		appHash, err := appContentHash(context.WorkingDir, config.SBOMMode, sbomScope,
+			context.BuildpackInfo.Version,
+			config.SBOMMode,
+			config.SBOMPaths,
+			config.SBOMExclude,
+			strings.Join(context.BuildpackInfo.SBOMFormats, ","),
+		)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		logger.Process("Checking for a cached SBOM")
+
+		var sbomFormatter packit.SBOMFormatter
+		cachedHash, ok := sbomCacheLayer.Metadata["app-hash"].(string)
+		switch {
+		case !ok:
+			logger.Subprocess("Cache miss: no SBOM was cached by a previous build")
+		case cachedHash != appHash:
+			logger.Subprocess("Cache miss: the app content, the SBOM settings or the buildpack version have changed since the previous build")
+		default:
+			var extensions []string
+			for _, format := range context.BuildpackInfo.SBOMFormats {
+				extensions = append(extensions, sbom.Format(format).Extension())
+			}
+
+			cachedSBOM, err := readSBOMCache(sbomCacheLayer.Path, extensions)
+			if err != nil {
+				logger.Subprocess("Cache miss: %s", err)
+			} else {
+				logger.Subprocess("Cache hit: reusing the SBOM of the previous build for %s", context.WorkingDir)
+				sbomFormatter = cachedSBOM
+			}
+		}
+		logger.Break()
+
+		if sbomFormatter == nil {
+			logger.GeneratingSBOM(context.WorkingDir)
+			for _, path := range sbomScope.Paths {
+				logger.Subprocess("Including %s", path)
+			}
+			for _, pattern := range sbomScope.Exclude {
+				logger.Subprocess("Excluding %s", pattern)
+			}
+
+			var sbomContent sbom.SBOM
+			duration, err := clock.Measure(func() error {
+				sbomContent, err = sbomGenerator.Generate(context.WorkingDir, sbomScope)
+				return err
+			})
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Action("Completed in %s", duration.Round(time.Millisecond))
+			logger.Break()
+
+			logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
+			formatter, err := sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			formatted, err := formatSBOM(formatter)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			sbomCacheLayer, err = sbomCacheLayer.Reset()
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			err = writeSBOMCache(sbomCacheLayer.Path, formatted)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			sbomCacheLayer.Metadata = map[string]interface{}{
+				"app-hash": appHash,
+			}
+
+			sbomFormatter = formatted
+		}
+		sbomCacheLayer.Cache = true
+
+		advisoryPaths, warning := advisoryDatabasePaths(config.AdvisoryDB, context.Platform.Path)
+		if warning != "" {
+			logger.Process("Warning: %s", warning)
+			logger.Break()
+		}
+
+		if len(advisoryPaths) > 0 {
+			logger.Process("Checking NuGet packages against the advisory database")
+			advisories, err := loadAdvisories(advisoryPaths)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			libraries, err := appNuGetLibraries(context.WorkingDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			matches := matchAdvisories(advisories, libraries)
+			if len(matches) == 0 {
+				logger.Subprocess("No advisories found")
+			} else {
+				logAdvisoryMatches(logger, matches)
+			}
+			logger.Break()
+
+			if failOnSeverity != "" {
+				var failing int
+				for _, match := range matches {
+					if severityRanks[match.Severity] >= severityRanks[failOnSeverity] {
+						failing++
+					}
+				}
+
+				if failing > 0 {
+					return packit.BuildResult{}, fmt.Errorf("found %d advisories with severity %s or higher", failing, failOnSeverity)
+				}
+			}
+		}
+
+		var processes []packit.Process
+		var defaultCommand string
+		for _, runtimeConfig := range runtimeConfigs {
+			command, args, err := appCommand(appRoot, runtimeConfig, appKinds[runtimeConfig.AppName])
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			if runtimeConfig.AppName == defaultApp {
+				defaultCommand = shellQuote(append([]string{command}, args...)...)
+			}
+			args = append(args, launchArgs...)
+
+			if config.LiveReloadEnabled {
+				processes = append(processes,
+					packit.Process{
+						Type:    fmt.Sprintf("reload-%s", runtimeConfig.AppName),
+						Command: "watchexec",
+						Args: append([]string{
+							"--restart",
+							"--watch", appRoot,
+							"--shell", "none",
+							"--",
+							command,
+						}, args...),
+						Default:          runtimeConfig.AppName == defaultApp,
+						Direct:           true,
+						WorkingDirectory: processWorkingDir,
+					},
+					packit.Process{
+						Type:             runtimeConfig.AppName,
+						Command:          command,
+						Args:             args,
+						Direct:           true,
+						WorkingDirectory: processWorkingDir,
+					},
+				)
+				continue
+			}
+
+			processes = append(processes, packit.Process{
+				Type:             runtimeConfig.AppName,
+				Command:          command,
+				Args:             args,
+				Default:          runtimeConfig.AppName == defaultApp,
+				Direct:           true,
+				WorkingDirectory: processWorkingDir,
+			})
+		}
+
+		var appExecutables []string
+		for _, runtimeConfig := range runtimeConfigs {
+			if runtimeConfig.Executable {
+				appExecutables = append(appExecutables, runtimeConfig.AppName)
+			}
+		}
+
+		migrationBundles, err := findMigrationBundles(appRoot, appExecutables)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		for _, bundle := range migrationBundles {
+			restored, err := restoreExecBit(bundle)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if restored {
+				logger.Process("Restored the exec bit of the '%s' migration bundle", filepath.Base(bundle))
+				logger.Break()
+			}
+
+			logger.Debug.Process("Found EF Core migration bundle '%s'", bundle)
+
+			command, args, err := migrationCommand(bundle, config.MigrateConnectionEnv)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			processes = append(processes, packit.Process{
+				Type:             migrationProcessType(bundle, len(migrationBundles)),
+				Command:          command,
+				Args:             args,
+				Direct:           true,
+				WorkingDirectory: processWorkingDir,
+			})
+		}
+		if len(migrationBundles) > 0 {
+			logger.Debug.Break()
+		}
+
+		extraProcesses, err := parseExtraProcesses(appRoot)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		usesPlaceholder, err := procfileUsesPlaceholder(appRoot)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if usesPlaceholder {
+			logger.Process("Warning: the Procfile references %s, which is only replaced in dotnet-execute.toml: declare the process types that use it there instead", AppCommandPlaceholder)
+			logger.Break()
+		}
+
+		for _, extraProcess := range extraProcesses {
+			for _, process := range processes {
+				if process.Type == extraProcess.Type {
+					return packit.BuildResult{}, fmt.Errorf("failed to add process type %q: a process of that type already exists", extraProcess.Type)
+				}
+			}
+
+			processes = append(processes, packit.Process{
+				Type:             extraProcess.Type,
+				Command:          "bash",
+				Args:             []string{"-c", strings.ReplaceAll(extraProcess.Command, AppCommandPlaceholder, defaultCommand)},
+				Direct:           true,
+				WorkingDirectory: processWorkingDir,
+			})
 		}
 
 		if config.LiveReloadEnabled {
-			processes = []packit.Process{
-				{
-					Type:    fmt.Sprintf("reload-%s", runtimeConfig.AppName),
-					Command: "watchexec",
-					Args: append([]string{
-						"--restart",
-						"--watch", context.WorkingDir,
-						"--shell", "none",
-						"--",
-						command,
-					}, args...),
-					Default: true,
-					Direct:  true,
-				},
-				{
-					Type:    runtimeConfig.AppName,
-					Command: command,
-					Args:    args,
-					Direct:  true,
-				},
-			}
-
-			err := filepath.Walk(context.WorkingDir, func(path string, info fs.FileInfo, err error) error {
+			err := filepath.Walk(appRoot, func(path string, info fs.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
-				if path == context.WorkingDir {
+				if path == appRoot {
 					return nil
 				}
 
@@ -152,12 +478,19 @@ func Build(
 			portChooserLayer.LaunchEnv.Default("ASPNETCORE_ENVIRONMENT", "Development")
 		}
 
+		// The port chooser picks the variable to pass the port through based on
+		// the framework version of the app
+		if frameworkVersion != "" {
+			portChooserLayer.LaunchEnv.Default("BPI_DOTNET_FRAMEWORK_VERSION", frameworkVersion)
+		}
+
 		logger.LayerFlags(portChooserLayer)
 		logger.EnvironmentVariables(portChooserLayer)
 
 		return packit.BuildResult{
 			Layers: []packit.Layer{
 				portChooserLayer,
+				sbomCacheLayer,
 			},
 			Launch: packit.LaunchMetadata{
 				Processes: processes,
@@ -166,3 +499,79 @@ func Build(
 		}, nil
 	}
 }
+
+// parseSBOMScope parses the colon-separated BP_DOTNET_SBOM_PATHS and
+// BP_DOTNET_SBOM_EXCLUDE lists.
+func parseSBOMScope(paths, exclude string) (SBOMScope, error) {
+	var scope SBOMScope
+	for _, path := range strings.Split(paths, ":") {
+		if path == "" {
+			continue
+		}
+
+		path = filepath.Clean(path)
+		if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") {
+			return SBOMScope{}, fmt.Errorf("invalid BP_DOTNET_SBOM_PATHS entry %q: must be a path inside the app directory", path)
+		}
+
+		scope.Paths = append(scope.Paths, path)
+	}
+
+	for _, pattern := range strings.Split(exclude, ":") {
+		if pattern == "" {
+			continue
+		}
+
+		if !doublestar.ValidatePattern(pattern) {
+			return SBOMScope{}, fmt.Errorf("invalid BP_DOTNET_SBOM_EXCLUDE pattern %q", pattern)
+		}
+
+		scope.Exclude = append(scope.Exclude, pattern)
+	}
+
+	return scope, nil
+}
+
+// defaultAppName returns the name of the app whose process should be the
+// default one: the configured one when set, otherwise the first app.
+func defaultAppName(name string, runtimeConfigs []RuntimeConfig) (string, error) {
+	if name == "" {
+		if len(runtimeConfigs) == 0 {
+			return "", nil
+		}
+		return runtimeConfigs[0].AppName, nil
+	}
+
+	for _, runtimeConfig := range runtimeConfigs {
+		if runtimeConfig.AppName == name {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to find default process %q: no %s.runtimeconfig.json found", name, name)
+}
+
+// appCommand returns the command and arguments that start the app described
+// by the given runtime configuration, according to its AppKind.
+func appCommand(root string, runtimeConfig RuntimeConfig, kind AppKind) (string, []string, error) {
+	switch kind {
+	case AppKindFrameworkDependentExecutable:
+		return filepath.Join(root, runtimeConfig.AppName), nil, nil
+	case AppKindSelfContainedExecutable:
+		if runtimeConfig.Executable {
+			return filepath.Join(root, runtimeConfig.AppName), nil, nil
+		}
+	}
+
+	// Framework-dependent deployments, and self-contained apps whose apphost
+	// is missing, are run through the dotnet host
+	_, err := os.Stat(filepath.Join(root, fmt.Sprintf("%s.dll", runtimeConfig.AppName)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil, fmt.Errorf("no entrypoint [%s.dll] found: %w ", runtimeConfig.AppName, err)
+	}
+
+	return "dotnet", []string{fmt.Sprintf("%s.dll", filepath.Join(root, runtimeConfig.AppName))}, nil
+}
@@ -2,11 +2,14 @@ package dotnetexecute_test
 
 import (
 	"bytes"
+	"debug/elf"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
@@ -65,10 +68,12 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 	context("the app is a framework-dependent or self-contained executable", func() {
 		it.Before(func() {
-			configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-				Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
-				AppName:    "my.app",
-				Executable: true,
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
 			}
 		})
 
@@ -89,7 +94,9 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(buffer.String()).To(ContainSubstring("Detected self-contained-executable app 'my.app'"))
+
+			Expect(result.Layers).To(HaveLen(2))
 			portLayer := result.Layers[0]
 
 			Expect(portLayer.Name).To(Equal("port-chooser"))
@@ -151,25 +158,29 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 			Expect(result.Launch.Processes).To(Equal([]packit.Process{
 				{
-					Type:    "my.app",
-					Command: filepath.Join(workingDir, "my.app"),
-					Default: true,
-					Direct:  true,
+					Type:             "my.app",
+					Command:          filepath.Join(workingDir, "my.app"),
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
 				},
 			}))
 
-			Expect(configParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(configParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 
 			Expect(sbomGenerator.GenerateCall.Receives.Path).To(Equal(workingDir))
+			Expect(sbomGenerator.GenerateCall.Receives.Scope).To(Equal(dotnetexecute.SBOMScope{}))
 		})
 	})
 
 	context("the app is a framework dependent deployment", func() {
 		it.Before(func() {
-			configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-				Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
-				AppName:    "my.app",
-				Executable: false,
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: false,
+				},
 			}
 			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, os.ModePerm)).To(Succeed())
 		})
@@ -195,7 +206,7 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers).To(HaveLen(2))
 			portLayer := result.Layers[0]
 
 			Expect(portLayer.Name).To(Equal("port-chooser"))
@@ -205,24 +216,138 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 			Expect(result.Launch.Processes).To(Equal([]packit.Process{
 				{
 
-					Type:    "my.app",
-					Command: "dotnet",
-					Args:    []string{filepath.Join(workingDir, "my.app.dll")},
-					Default: true,
-					Direct:  true,
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.app.dll")},
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+			}))
+		})
+	})
+
+	context("the app directory contains multiple runtimeconfig.json files", func() {
+		it.Before(func() {
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.api.runtimeconfig.json"),
+					AppName:    "my.api",
+					Executable: true,
+				},
+				{
+					Path:    filepath.Join(workingDir, "my.worker.runtimeconfig.json"),
+					AppName: "my.worker",
+				},
+			}
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.worker.dll"), nil, os.ModePerm)).To(Succeed())
+		})
+
+		it("returns a process for each of them", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:             "my.api",
+					Command:          filepath.Join(workingDir, "my.api"),
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:             "my.worker",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.worker.dll")},
+					Direct:           true,
+					WorkingDirectory: workingDir,
 				},
 			}))
 		})
+
+		context("when BP_DOTNET_DEFAULT_PROCESS is set", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					DefaultProcess: "my.worker",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("makes that process the default", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:             "my.api",
+						Command:          filepath.Join(workingDir, "my.api"),
+						Direct:           true,
+						WorkingDirectory: workingDir,
+					},
+					{
+						Type:             "my.worker",
+						Command:          "dotnet",
+						Args:             []string{filepath.Join(workingDir, "my.worker.dll")},
+						Default:          true,
+						Direct:           true,
+						WorkingDirectory: workingDir,
+					},
+				}))
+			})
+		})
+
+		context("when BP_DOTNET_DEFAULT_PROCESS does not match any app", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					DefaultProcess: "my.other",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`failed to find default process "my.other": no my.other.runtimeconfig.json found`))
+			})
+		})
 	})
 
 	context("when BP_LIVE_RELOAD_ENABLED=true", func() {
 		it.Before(func() {
 			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, os.ModePerm)).To(Succeed())
 
-			configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-				Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
-				AppName:    "my.app",
-				Executable: false,
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: false,
+				},
 			}
 
 			err := filepath.Walk(workingDir, func(path string, info fs.FileInfo, err error) error {
@@ -276,14 +401,16 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 						"dotnet",
 						filepath.Join(workingDir, "my.app.dll"),
 					},
-					Default: true,
-					Direct:  true,
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
 				},
 				{
-					Type:    "my.app",
-					Command: "dotnet",
-					Args:    []string{filepath.Join(workingDir, "my.app.dll")},
-					Direct:  true,
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.app.dll")},
+					Direct:           true,
+					WorkingDirectory: workingDir,
 				},
 			}))
 		})
@@ -322,25 +449,26 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
-	context("when BP_DEBUG_ENABLED=true", func() {
+	context("when BP_DOTNET_PROJECT_PATH is set", func() {
 		it.Before(func() {
-			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, os.ModePerm)).To(Succeed())
-			configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-				Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
-				AppName:    "my.app",
-				Executable: false,
+			Expect(os.MkdirAll(filepath.Join(workingDir, "src", "proj1"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "src", "proj1", "my.app.dll"), nil, 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "other-file"), nil, 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:    filepath.Join(workingDir, "src", "proj1", "my.app.runtimeconfig.json"),
+					AppName: "my.app",
+				},
 			}
 
 			build = dotnetexecute.Build(dotnetexecute.Configuration{
-				DebugEnabled: true,
+				ProjectPath:       "src/proj1",
+				LiveReloadEnabled: true,
 			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
 		})
 
-		it.After(func() {
-			Expect(os.RemoveAll(filepath.Join(workingDir, "my.app.dll"))).To(Succeed())
-		})
-
-		it("sets ASPNETCORE_ENVIRONMENT=Development at launch time", func() {
+		it("runs the app from the project path", func() {
 			result, err := build(packit.BuildContext{
 				WorkingDir: workingDir,
 				CNBPath:    cnbDir,
@@ -350,157 +478,1664 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 					Version:     "some-version",
 					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
 				},
-				Plan: packit.BuildpackPlan{
-					Entries: []packit.BuildpackPlanEntry{},
-				},
 				Layers: packit.Layers{Path: layersDir},
 			})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(result.Layers).To(HaveLen(1))
-			portLayer := result.Layers[0]
-
-			Expect(portLayer.Name).To(Equal("port-chooser"))
-			Expect(portLayer.Path).To(Equal(filepath.Join(layersDir, "port-chooser")))
-			Expect(portLayer.ExecD).To(Equal([]string{filepath.Join(cnbDir, "bin", "port-chooser")}))
-
-			Expect(portLayer.LaunchEnv).To(Equal(packit.Environment{
-				"ASPNETCORE_ENVIRONMENT.default": "Development",
-			}))
+			Expect(configParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "src", "proj1", "*.runtimeconfig.json")))
 
 			Expect(result.Launch.Processes).To(Equal([]packit.Process{
 				{
-					Type:    "my.app",
-					Command: "dotnet",
-					Args:    []string{filepath.Join(workingDir, "my.app.dll")},
-					Direct:  true,
-					Default: true,
+					Type:    "reload-my.app",
+					Command: "watchexec",
+					Args: []string{
+						"--restart",
+						"--watch", filepath.Join(workingDir, "src", "proj1"),
+						"--shell", "none",
+						"--",
+						"dotnet",
+						filepath.Join(workingDir, "src", "proj1", "my.app.dll"),
+					},
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: filepath.Join(workingDir, "src", "proj1"),
+				},
+				{
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "src", "proj1", "my.app.dll")},
+					Direct:           true,
+					WorkingDirectory: filepath.Join(workingDir, "src", "proj1"),
 				},
 			}))
+
+			info, err := os.Stat(filepath.Join(workingDir, "src", "proj1", "my.app.dll"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(fs.FileMode(0660)))
+
+			info, err = os.Stat(filepath.Join(workingDir, "other-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode()).To(Equal(fs.FileMode(0600)))
 		})
-	})
 
-	context("failure cases", func() {
-		context("runtime config parsing fails", func() {
+		context("when the project path does not contain a runtimeconfig.json", func() {
 			it.Before(func() {
-				configParser.ParseCall.Returns.Error = errors.New("error parsing runtimeconfig.json")
+				Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, 0600)).To(Succeed())
+
+				configParser.ParseAllCall.Stub = func(glob string) ([]dotnetexecute.RuntimeConfig, error) {
+					if glob != filepath.Join(workingDir, "*.runtimeconfig.json") {
+						return nil, fmt.Errorf("no *.runtimeconfig.json found: %w", os.ErrNotExist)
+					}
+
+					return []dotnetexecute.RuntimeConfig{
+						{
+							Path:    filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+							AppName: "my.app",
+						},
+					}, nil
+				}
 			})
 
-			it("returns an error", func() {
-				_, err := build(packit.BuildContext{
+			it("falls back to the working directory the source app was published into", func() {
+				result, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
 					CNBPath:    cnbDir,
 					Stack:      "some-stack",
 					BuildpackInfo: packit.BuildpackInfo{
-						Name:    "Some Buildpack",
-						Version: "some-version",
-					},
-					Plan: packit.BuildpackPlan{
-						Entries: []packit.BuildpackPlanEntry{},
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
 					},
 					Layers: packit.Layers{Path: layersDir},
 				})
-				Expect(err).To(MatchError(ContainSubstring("error parsing runtimeconfig.json")))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(configParser.ParseAllCall.CallCount).To(Equal(2))
+				Expect(result.Launch.Processes).To(ContainElement(packit.Process{
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.app.dll")},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				}))
 			})
 		})
+	})
 
-		context("error when checking for existence of dll file", func() {
+	context("when BP_DOTNET_WORKING_DIRECTORY is set", func() {
+		it.Before(func() {
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
+			}
+		})
+
+		context("to a relative path", func() {
 			it.Before(func() {
-				configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
-					AppName:    "myapp",
-					Executable: false,
-				}
-				Expect(os.Chmod(workingDir, 0000)).To(Succeed())
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					WorkingDirectory: "content",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
 			})
 
-			it.After(func() {
-				Expect(os.Chmod(workingDir, os.ModePerm)).To(Succeed())
-			})
-			it("returns an error", func() {
-				_, err := build(packit.BuildContext{
+			it("resolves it against the working directory", func() {
+				result, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
 					CNBPath:    cnbDir,
 					Stack:      "some-stack",
 					BuildpackInfo: packit.BuildpackInfo{
-						Name:    "Some Buildpack",
-						Version: "some-version",
-					},
-					Plan: packit.BuildpackPlan{
-						Entries: []packit.BuildpackPlanEntry{},
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
 					},
 					Layers: packit.Layers{Path: layersDir},
 				})
-				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:             "my.app",
+						Command:          filepath.Join(workingDir, "my.app"),
+						Default:          true,
+						Direct:           true,
+						WorkingDirectory: filepath.Join(workingDir, "content"),
+					},
+				}))
 			})
 		})
 
-		context("neither executable nor dll file are present (no entrypoint is found)", func() {
+		context("to an absolute path", func() {
 			it.Before(func() {
-				configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
-					AppName:    "myapp",
-					Executable: false,
-				}
-				files, err := filepath.Glob(filepath.Join(workingDir, "*.dll"))
-				Expect(err).NotTo(HaveOccurred())
-				Expect(files).To(BeEmpty())
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					WorkingDirectory: "/some/content/root",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
 			})
 
-			it("returns an error", func() {
-				_, err := build(packit.BuildContext{
+			it("uses it as is", func() {
+				result, err := build(packit.BuildContext{
 					WorkingDir: workingDir,
 					CNBPath:    cnbDir,
 					Stack:      "some-stack",
 					BuildpackInfo: packit.BuildpackInfo{
-						Name:    "Some Buildpack",
-						Version: "some-version",
-					},
-					Plan: packit.BuildpackPlan{
-						Entries: []packit.BuildpackPlanEntry{},
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
 					},
 					Layers: packit.Layers{Path: layersDir},
 				})
-				Expect(err).To(MatchError(ContainSubstring("no entrypoint [myapp.dll] found")))
-			})
+				Expect(err).NotTo(HaveOccurred())
 
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:             "my.app",
+						Command:          filepath.Join(workingDir, "my.app"),
+						Default:          true,
+						Direct:           true,
+						WorkingDirectory: "/some/content/root",
+					},
+				}))
+			})
 		})
+	})
 
-		context("when generating the SBOM returns an error", func() {
-			it.Before(func() {
-				sbomGenerator.GenerateCall.Returns.Error = errors.New("failed to generate SBOM")
+	context("when the apphost has lost its exec bit", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, true), 0600)).To(Succeed())
 
-				configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
-					AppName:    "myapp",
-					Executable: true,
-				}
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:        "my.app",
+					RuntimeVersion: "8.0.0",
+					Executable:     true,
+				},
+			}
+		})
+
+		it("restores it", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
 			})
+			Expect(err).NotTo(HaveOccurred())
 
-			it("returns an error", func() {
-				_, err := build(packit.BuildContext{
-					WorkingDir: workingDir,
-					CNBPath:    cnbDir,
-					Stack:      "some-stack",
-					BuildpackInfo: packit.BuildpackInfo{
-						Name:    "Some Buildpack",
-						Version: "some-version",
-					},
-					Plan: packit.BuildpackPlan{
-						Entries: []packit.BuildpackPlanEntry{},
-					},
-					Layers: packit.Layers{Path: layersDir},
-				})
-				Expect(err).To(MatchError(ContainSubstring("failed to generate SBOM")))
+			info, err := os.Stat(filepath.Join(workingDir, "my.app"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0711)))
+
+			Expect(result.Launch.Processes[0].Command).To(Equal(filepath.Join(workingDir, "my.app")))
+			Expect(buffer.String()).To(ContainSubstring("Restored the exec bit of the 'my.app' apphost"))
+		})
+	})
+
+	context("when the app carries native libraries for several architectures", func() {
+		it.Before(func() {
+			t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+			for rid, machine := range map[string]elf.Machine{"linux-x64": elf.EM_X86_64, "linux-arm64": elf.EM_AARCH64} {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", rid, "native"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", rid, "native", "libe_sqlite3.so"), elfExecutable(machine, elf.ET_DYN, false), 0600)).To(Succeed())
+			}
+			Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", "win-x64", "native"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", "win-x64", "native", "e_sqlite3.dll"), []byte("MZ"), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:        "my.app",
+					RuntimeVersion: "8.0.0",
+					Executable:     true,
+					Arch:           "amd64",
+				},
+			}
+		})
+
+		it("builds as one of them matches the target architecture", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
 			})
+			Expect(err).NotTo(HaveOccurred())
 		})
+	})
 
-		context("when formatting the SBOM returns an error", func() {
-			it.Before(func() {
-				configParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
-					AppName:    "myapp",
-					Executable: true,
+	context("when the app carries native libraries only published for musl on a glibc stack", func() {
+		it.Before(func() {
+			t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", "linux-musl-x64", "native"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", "linux-musl-x64", "native", "libe_sqlite3.so"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, false), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:        "my.app",
+					RuntimeVersion: "8.0.0",
+				},
+			}
+		})
+
+		it("warns that the app may fail to start", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Warning: the native libraries of the app were only published for linux-musl-x64, but the stack uses glibc: the app may fail to start unless it is published with --runtime linux-x64"))
+		})
+	})
+
+	context("when the app carries native libraries published for musl on a musl stack", func() {
+		it.Before(func() {
+			t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", "linux-musl-x64", "native"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", "linux-musl-x64", "native", "libe_sqlite3.so"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, false), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:        "my.app",
+					RuntimeVersion: "8.0.0",
+				},
+			}
+		})
+
+		it("builds as they match the target architecture", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-alpine-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	context("when the app was published for musl on a glibc stack", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte(`{
+  "runtimeTarget": {"name": ".NETCoreApp,Version=v8.0/linux-musl-x64"},
+  "libraries": {}
+}`), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
+			}
+		})
+
+		it("warns about it", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "io.buildpacks.stacks.jammy",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring("Warning: app 'my.app' was published for linux-musl-x64, but the stack uses glibc: the app may fail to start unless it is published with --runtime linux-x64"))
+		})
+
+		context("when the stack uses musl", func() {
+			it("does not warn", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-alpine-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+			})
+		})
+	})
+
+	context("when the framework version of the app is known", func() {
+		it.Before(func() {
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:        "my.app",
+					RuntimeVersion: "8.0.1",
+					Executable:     true,
+				},
+			}
+		})
+
+		it("passes it to the port chooser", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers[0].Name).To(Equal("port-chooser"))
+			Expect(result.Layers[0].LaunchEnv).To(Equal(packit.Environment{
+				"BPI_DOTNET_FRAMEWORK_VERSION.default": "8.0.1",
+			}))
+		})
+
+		context("when the app is self-contained", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte(`{
+  "runtimeTarget": {"name": ".NETCoreApp,Version=v9.0/linux-x64"},
+  "libraries": {}
+}`), 0600)).To(Succeed())
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName:    "my.app",
+						Executable: true,
+					},
+				}
+			})
+
+			it("takes it from the deps.json file", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv).To(Equal(packit.Environment{
+					"BPI_DOTNET_FRAMEWORK_VERSION.default": "9.0",
+				}))
+			})
+		})
+
+		context("when another app targets an older .NET version", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = append(configParser.ParseAllCall.Returns.RuntimeConfigSlice,
+					dotnetexecute.RuntimeConfig{
+						Path:           filepath.Join(workingDir, "my.worker.runtimeconfig.json"),
+						AppName:        "my.worker",
+						RuntimeVersion: "6.0.25",
+						Executable:     true,
+					},
+				)
+			})
+
+			it("passes the oldest version to the port chooser", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv).To(Equal(packit.Environment{
+					"BPI_DOTNET_FRAMEWORK_VERSION.default": "6.0.25",
+				}))
+			})
+		})
+
+		context("when the framework version of another app is unknown", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = append(configParser.ParseAllCall.Returns.RuntimeConfigSlice,
+					dotnetexecute.RuntimeConfig{
+						Path:           filepath.Join(workingDir, "my.worker.runtimeconfig.json"),
+						AppName:        "my.worker",
+						RuntimeVersion: "*",
+						Executable:     true,
+					},
+				)
+			})
+
+			it("does not pass a version to the port chooser", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv).To(BeEmpty())
+			})
+		})
+	})
+
+	context("when BP_DOTNET_LAUNCH_ARGS is set", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:    filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName: "my.app",
+				},
+			}
+
+			build = dotnetexecute.Build(dotnetexecute.Configuration{
+				LiveReloadEnabled: true,
+				LaunchArgs:        `--urls "http://+:9000"  --feature\ switch 'it'"'"'s' "a \"quoted\" \value"`,
+			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+		})
+
+		it("appends the arguments to the app processes", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "reload-my.app",
+					Command: "watchexec",
+					Args: []string{
+						"--restart",
+						"--watch", workingDir,
+						"--shell", "none",
+						"--",
+						"dotnet",
+						filepath.Join(workingDir, "my.app.dll"),
+						"--urls", "http://+:9000",
+						"--feature switch",
+						"it's",
+						`a "quoted" \value`,
+					},
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:    "my.app",
+					Command: "dotnet",
+					Args: []string{
+						filepath.Join(workingDir, "my.app.dll"),
+						"--urls", "http://+:9000",
+						"--feature switch",
+						"it's",
+						`a "quoted" \value`,
+					},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+			}))
+		})
+	})
+
+	context("the app root contains EF Core migration bundles", func() {
+		it.Before(func() {
+			executable := elfExecutable(elf.EM_X86_64, elf.ET_DYN, true)
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app"), append(executable, []byte("efbundle.dll")...), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "efbundle"), executable, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "efbundle.deps.json"), nil, 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "other-tool"), append(executable, bytes.Repeat([]byte("x"), 100*1024)...), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "run.sh"), []byte("#!/bin/sh\n# efbundle.dll\n"), 0755)).To(Succeed())
+
+			// The signature straddles two of the chunks the bundle is read in
+			content := append(executable, bytes.Repeat([]byte("x"), 64*1024-5)...)
+			content = append(content, []byte("efbundle.dll")...)
+			Expect(os.WriteFile(filepath.Join(workingDir, "db-migrations"), content, 0644)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
+			}
+		})
+
+		it("adds a migrate process for each of them", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:             "my.app",
+					Command:          filepath.Join(workingDir, "my.app"),
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:             "migrate-db-migrations",
+					Command:          filepath.Join(workingDir, "db-migrations"),
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:             "migrate-efbundle",
+					Command:          filepath.Join(workingDir, "efbundle"),
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+			}))
+
+			for _, name := range []string{"db-migrations", "efbundle"} {
+				info, err := os.Stat(filepath.Join(workingDir, name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode() & 0111).NotTo(BeZero())
+			}
+			Expect(buffer.String()).To(ContainSubstring("Restored the exec bit of the 'db-migrations' migration bundle"))
+			Expect(buffer.String()).To(ContainSubstring("Restored the exec bit of the 'efbundle' migration bundle"))
+		})
+
+		context("when there is a single bundle and BP_DOTNET_MIGRATE_CONNECTION_ENV is set", func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(workingDir, "db-migrations"))).To(Succeed())
+
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					MigrateConnectionEnv: "DB_CONNECTION",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("adds a migrate process that passes the connection string", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(ContainElement(packit.Process{
+					Type:    "migrate",
+					Command: "bash",
+					Args: []string{
+						"-c",
+						fmt.Sprintf(`exec %s --connection "${DB_CONNECTION}"`, filepath.Join(workingDir, "efbundle")),
+					},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				}))
+			})
+		})
+
+		context("when BP_DOTNET_MIGRATE_CONNECTION_ENV is not an environment variable name", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					MigrateConnectionEnv: "$(reboot)",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`invalid BP_DOTNET_MIGRATE_CONNECTION_ENV: "$(reboot)" is not an environment variable name`))
+			})
+		})
+	})
+
+	context("the app root contains a dotnet-execute.toml", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "dotnet-execute.toml"), []byte(`
+[[processes]]
+type = "seed"
+command = "{{app}} --seed 'sample data'"
+
+[[processes]]
+type = "admin-cli"
+command = "./tools/admin --verbose"
+`), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:    filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName: "my.app",
+				},
+			}
+
+			build = dotnetexecute.Build(dotnetexecute.Configuration{
+				LaunchArgs: "--urls http://+:9000",
+			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+		})
+
+		it("adds the declared process types", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			appCommand := fmt.Sprintf("dotnet %s", filepath.Join(workingDir, "my.app.dll"))
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.app.dll"), "--urls", "http://+:9000"},
+					Default:          true,
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:             "seed",
+					Command:          "bash",
+					Args:             []string{"-c", fmt.Sprintf("%s --seed 'sample data'", appCommand)},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+				{
+					Type:             "admin-cli",
+					Command:          "bash",
+					Args:             []string{"-c", "./tools/admin --verbose"},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+				},
+			}))
+		})
+
+		context("when the app root also contains a Procfile that uses the placeholder", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "Procfile"), []byte("migrate: {{app}} --migrate\n"), 0600)).To(Succeed())
+			})
+
+			it("leaves the Procfile to the Procfile buildpack and warns about the placeholder", func() {
+				result, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:        "Some Buildpack",
+						Version:     "some-version",
+						SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				var types []string
+				for _, process := range result.Launch.Processes {
+					types = append(types, process.Type)
+				}
+				Expect(types).To(Equal([]string{"my.app", "seed", "admin-cli"}))
+
+				Expect(buffer.String()).To(ContainSubstring("Warning: the Procfile references {{app}}, which is only replaced in dotnet-execute.toml: declare the process types that use it there instead"))
+			})
+		})
+	})
+
+	context("when BP_DOTNET_SBOM_PATHS and BP_DOTNET_SBOM_EXCLUDE are set", func() {
+		it.Before(func() {
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
+			}
+
+			build = dotnetexecute.Build(dotnetexecute.Configuration{
+				SBOMPaths:   "./my.app.deps.json::lib/",
+				SBOMExclude: "wwwroot:ClientApp/**/node_modules",
+			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+		})
+
+		it("generates the SBOM within that scope", func() {
+			_, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sbomGenerator.GenerateCall.Receives.Path).To(Equal(workingDir))
+			Expect(sbomGenerator.GenerateCall.Receives.Scope).To(Equal(dotnetexecute.SBOMScope{
+				Paths:   []string{"my.app.deps.json", "lib"},
+				Exclude: []string{"wwwroot", "ClientApp/**/node_modules"},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Including my.app.deps.json"))
+			Expect(buffer.String()).To(ContainSubstring("Excluding ClientApp/**/node_modules"))
+		})
+	})
+
+	context("when the SBOM is cached", func() {
+		var buildContext packit.BuildContext
+
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), []byte("some-app"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte("{}"), 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "wwwroot", "lib"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "wwwroot", "lib", "package.json"), []byte(`{"version": "3.7.0"}`), 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workingDir, "node_modules"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "node_modules", "package.json"), []byte(`{}`), 0600)).To(Succeed())
+
+			build = dotnetexecute.Build(dotnetexecute.Configuration{
+				SBOMMode:    "scan",
+				SBOMExclude: "node_modules",
+			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:    filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName: "my.app",
+				},
+			}
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(1))
+
+			Expect(buffer.String()).To(ContainSubstring("Checking for a cached SBOM"))
+			Expect(buffer.String()).To(ContainSubstring("Cache miss: no SBOM was cached by a previous build"))
+
+			Expect(result.Layers).To(HaveLen(2))
+			sbomCacheLayer := result.Layers[1]
+			Expect(sbomCacheLayer.Name).To(Equal("sbom-cache"))
+			Expect(sbomCacheLayer.Build).To(BeFalse())
+			Expect(sbomCacheLayer.Launch).To(BeFalse())
+			Expect(sbomCacheLayer.Cache).To(BeTrue())
+			Expect(sbomCacheLayer.Metadata).To(HaveKeyWithValue("app-hash", MatchRegexp(`^[0-9a-f]{64}$`)))
+			Expect(filepath.Join(layersDir, "sbom-cache", "sbom.cdx.json")).To(BeARegularFile())
+			Expect(filepath.Join(layersDir, "sbom-cache", "sbom.spdx.json")).To(BeARegularFile())
+
+			// packit persists the layer metadata once the build completes
+			Expect(os.WriteFile(filepath.Join(layersDir, "sbom-cache.toml"), []byte(fmt.Sprintf(`
+cache = true
+
+[metadata]
+  app-hash = %q
+`, sbomCacheLayer.Metadata["app-hash"])), 0600)).To(Succeed())
+
+			buffer.Reset()
+		})
+
+		it("reuses the cached SBOM when the app content has not changed", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(1))
+
+			Expect(buffer.String()).To(ContainSubstring("Checking for a cached SBOM"))
+			Expect(buffer.String()).To(ContainSubstring("Cache hit: reusing the SBOM of the previous build"))
+			Expect(buffer.String()).NotTo(ContainSubstring("Generating SBOM"))
+
+			Expect(result.Layers[1].Cache).To(BeTrue())
+
+			formats := result.Launch.SBOM.Formats()
+			Expect(formats).To(HaveLen(2))
+			Expect(formats[0].Extension).To(Equal("cdx.json"))
+			Expect(formats[1].Extension).To(Equal("spdx.json"))
+
+			content, err := io.ReadAll(formats[0].Content)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"bomFormat": "CycloneDX"`))
+		})
+
+		it("regenerates the SBOM when a DLL has changed", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), []byte("some-other-app"), 0600)).To(Succeed())
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(2))
+
+			Expect(buffer.String()).To(ContainSubstring("Cache miss: the app content, the SBOM settings or the buildpack version have changed since the previous build"))
+			Expect(buffer.String()).To(ContainSubstring("Generating SBOM"))
+		})
+
+		it("regenerates the SBOM when another file in the SBOM scope has changed", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "wwwroot", "lib", "package.json"), []byte(`{"version": "3.7.1"}`), 0600)).To(Succeed())
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(2))
+
+			Expect(buffer.String()).To(ContainSubstring("Cache miss: the app content, the SBOM settings or the buildpack version have changed since the previous build"))
+		})
+
+		it("reuses the cached SBOM when only an excluded file has changed", func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "node_modules", "package.json"), []byte(`{"version": "1.0.0"}`), 0600)).To(Succeed())
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(1))
+		})
+
+		it("regenerates the SBOM when the buildpack version has changed", func() {
+			buildContext.BuildpackInfo.Version = "some-other-version"
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(2))
+
+			Expect(buffer.String()).To(ContainSubstring("Cache miss: the app content, the SBOM settings or the buildpack version have changed since the previous build"))
+		})
+
+		it("regenerates the SBOM when other SBOM formats are requested", func() {
+			buildContext.BuildpackInfo.SBOMFormats = []string{sbom.SyftFormat}
+
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(2))
+
+			Expect(result.Launch.SBOM.Formats()).To(HaveLen(1))
+			Expect(filepath.Join(layersDir, "sbom-cache", "sbom.syft.json")).To(BeARegularFile())
+			Expect(filepath.Join(layersDir, "sbom-cache", "sbom.cdx.json")).NotTo(BeAnExistingFile())
+		})
+
+		it("regenerates the SBOM when the cached files are missing", func() {
+			Expect(os.Remove(filepath.Join(layersDir, "sbom-cache", "sbom.spdx.json"))).To(Succeed())
+
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sbomGenerator.GenerateCall.CallCount).To(Equal(2))
+		})
+	})
+
+	context("when an advisory database is given", func() {
+		var (
+			advisoryDir  string
+			buildContext packit.BuildContext
+		)
+
+		it.Before(func() {
+			var err error
+			advisoryDir, err = os.MkdirTemp("", "advisories")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(advisoryDir, "advisories.json"), []byte(`[
+  {
+    "id": "GHSA-5crp-9r3c-p9vr",
+    "affected": [
+      {
+        "package": {"ecosystem": "NuGet", "name": "Newtonsoft.Json"},
+        "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "13.0.1"}]}]
+      }
+    ],
+    "database_specific": {"severity": "HIGH"}
+  },
+  {
+    "id": "GHSA-some-moderate",
+    "affected": [
+      {
+        "package": {"ecosystem": "NuGet", "name": "system.text.encodings.web"},
+        "versions": ["4.5.0"]
+      }
+    ],
+    "database_specific": {"severity": "MODERATE"}
+  },
+  {
+    "id": "GHSA-some-prerelease",
+    "affected": [
+      {
+        "package": {"ecosystem": "NuGet", "name": "Some.Prerelease.Package"},
+        "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.0.0-beta.2"}, {"fixed": "2.0.0-beta.10"}]}]
+      }
+    ],
+    "database_specific": {"severity": "LOW"}
+  },
+  {
+    "id": "GHSA-not-affected",
+    "affected": [
+      {
+        "package": {"ecosystem": "NuGet", "name": "Some.Other.Package"},
+        "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "2.0.0"}, {"fixed": "2.1.0"}]}]
+      }
+    ],
+    "database_specific": {"severity": "CRITICAL"}
+  }
+]`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte(`{
+  "libraries": {
+    "my.app/1.0.0": {"type": "project"},
+    "Newtonsoft.Json/12.0.3": {"type": "package"},
+    "System.Text.Encodings.Web/4.5.0": {"type": "package"},
+    "Some.Prerelease.Package/2.0.0-beta.9": {"type": "package"},
+    "Some.Other.Package/1.0.0": {"type": "package"}
+  }
+}`), 0600)).To(Succeed())
+
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: true,
+				},
+			}
+
+			buildContext = packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:    "Some Buildpack",
+					Version: "some-version",
+				},
+				Layers: packit.Layers{Path: layersDir},
+			}
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(advisoryDir)).To(Succeed())
+		})
+
+		context("through BP_DOTNET_ADVISORY_DB", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					AdvisoryDB: advisoryDir,
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("logs the advisories that affect the NuGet packages of the app", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Checking NuGet packages against the advisory database"))
+				Expect(buffer.String()).To(MatchRegexp(`PACKAGE\s+VERSION\s+ADVISORY\s+SEVERITY\s+FIXED IN`))
+				Expect(buffer.String()).To(MatchRegexp(`Newtonsoft.Json\s+12.0.3\s+GHSA-5crp-9r3c-p9vr\s+HIGH\s+13.0.1`))
+				Expect(buffer.String()).To(MatchRegexp(`System.Text.Encodings.Web\s+4.5.0\s+GHSA-some-moderate\s+MODERATE\s+-`))
+				Expect(buffer.String()).To(MatchRegexp(`Some.Prerelease.Package\s+2.0.0-beta.9\s+GHSA-some-prerelease\s+LOW\s+2.0.0-beta.10`))
+				Expect(buffer.String()).NotTo(ContainSubstring("GHSA-not-affected"))
+			})
+
+			context("when several apps share a package", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "my.worker.deps.json"), []byte(`{
+  "libraries": {
+    "my.worker/1.0.0": {"type": "project"},
+    "newtonsoft.json/12.0.3": {"type": "package"}
+  }
+}`), 0600)).To(Succeed())
+
+					build = dotnetexecute.Build(dotnetexecute.Configuration{
+						AdvisoryDB:     advisoryDir,
+						FailOnSeverity: "high",
+					}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+				})
+
+				it("reports the package once", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("found 1 advisories with severity HIGH or higher"))
+
+					Expect(strings.Count(buffer.String(), "GHSA-5crp-9r3c-p9vr")).To(Equal(1))
+				})
+			})
+
+			context("when BP_DOTNET_FAIL_ON_SEVERITY is met", func() {
+				it.Before(func() {
+					build = dotnetexecute.Build(dotnetexecute.Configuration{
+						AdvisoryDB:     advisoryDir,
+						FailOnSeverity: "medium",
+					}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("found 2 advisories with severity MODERATE or higher"))
+				})
+			})
+
+			context("when BP_DOTNET_FAIL_ON_SEVERITY is not met", func() {
+				it.Before(func() {
+					build = dotnetexecute.Build(dotnetexecute.Configuration{
+						AdvisoryDB:     advisoryDir,
+						FailOnSeverity: "critical",
+					}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+				})
+
+				it("does not fail the build", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+		})
+
+		context("through a dotnet-advisory-db binding", func() {
+			it.Before(func() {
+				bindingDir := filepath.Join(advisoryDir, "bindings", "advisories")
+				Expect(os.MkdirAll(bindingDir, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(bindingDir, "type"), []byte("dotnet-advisory-db"), 0600)).To(Succeed())
+				Expect(os.Rename(filepath.Join(advisoryDir, "advisories.json"), filepath.Join(bindingDir, "osv.json"))).To(Succeed())
+
+				t.Setenv("SERVICE_BINDING_ROOT", filepath.Join(advisoryDir, "bindings"))
+			})
+
+			it("checks the NuGet packages against it", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("GHSA-5crp-9r3c-p9vr"))
+				Expect(buffer.String()).To(ContainSubstring("GHSA-some-moderate"))
+			})
+		})
+
+		context("when an unrelated service binding is malformed", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(advisoryDir, "bindings", "some-broken-binding"), os.ModePerm)).To(Succeed())
+				t.Setenv("SERVICE_BINDING_ROOT", filepath.Join(advisoryDir, "bindings"))
+			})
+
+			it("warns and does not fail the build", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("Warning: failed to resolve dotnet-advisory-db binding: "))
+				Expect(buffer.String()).NotTo(ContainSubstring("Checking NuGet packages against the advisory database"))
+			})
+
+			context("through BP_DOTNET_ADVISORY_DB", func() {
+				it.Before(func() {
+					build = dotnetexecute.Build(dotnetexecute.Configuration{
+						AdvisoryDB: advisoryDir,
+					}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+				})
+
+				it("still checks the NuGet packages against it", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(buffer.String()).To(ContainSubstring("GHSA-5crp-9r3c-p9vr"))
+				})
+			})
+		})
+
+		context("when no advisory affects the app", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte(`{
+  "libraries": {
+    "Newtonsoft.Json/13.0.3": {"type": "package"}
+  }
+}`), 0600)).To(Succeed())
+
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					AdvisoryDB:     filepath.Join(advisoryDir, "advisories.json"),
+					FailOnSeverity: "low",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("says so", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring("No advisories found"))
+			})
+		})
+	})
+
+	context("when BP_DEBUG_ENABLED=true", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "my.app.dll"), nil, os.ModePerm)).To(Succeed())
+			configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+					AppName:    "my.app",
+					Executable: false,
+				},
+			}
+
+			build = dotnetexecute.Build(dotnetexecute.Configuration{
+				DebugEnabled: true,
+			}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(filepath.Join(workingDir, "my.app.dll"))).To(Succeed())
+		})
+
+		it("sets ASPNETCORE_ENVIRONMENT=Development at launch time", func() {
+			result, err := build(packit.BuildContext{
+				WorkingDir: workingDir,
+				CNBPath:    cnbDir,
+				Stack:      "some-stack",
+				BuildpackInfo: packit.BuildpackInfo{
+					Name:        "Some Buildpack",
+					Version:     "some-version",
+					SBOMFormats: []string{sbom.CycloneDXFormat, sbom.SPDXFormat},
+				},
+				Plan: packit.BuildpackPlan{
+					Entries: []packit.BuildpackPlanEntry{},
+				},
+				Layers: packit.Layers{Path: layersDir},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(2))
+			portLayer := result.Layers[0]
+
+			Expect(portLayer.Name).To(Equal("port-chooser"))
+			Expect(portLayer.Path).To(Equal(filepath.Join(layersDir, "port-chooser")))
+			Expect(portLayer.ExecD).To(Equal([]string{filepath.Join(cnbDir, "bin", "port-chooser")}))
+
+			Expect(portLayer.LaunchEnv).To(Equal(packit.Environment{
+				"ASPNETCORE_ENVIRONMENT.default": "Development",
+			}))
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:             "my.app",
+					Command:          "dotnet",
+					Args:             []string{filepath.Join(workingDir, "my.app.dll")},
+					Direct:           true,
+					WorkingDirectory: workingDir,
+					Default:          true,
+				},
+			}))
+		})
+	})
+
+	context("failure cases", func() {
+		context("runtime config parsing fails", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.Error = errors.New("error parsing runtimeconfig.json")
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("error parsing runtimeconfig.json")))
+			})
+		})
+
+		context("BP_DOTNET_LAUNCH_ARGS cannot be parsed", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					LaunchArgs: `--urls "http://+:9000`,
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("failed to parse BP_DOTNET_LAUNCH_ARGS: unterminated quoted string"))
+			})
+		})
+
+		context("the dotnet-execute.toml is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "dotnet-execute.toml"), []byte("[[processes]]\ntype = \"seed\"\n"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("failed to parse dotnet-execute.toml: processes[0] must set both type and command"))
+			})
+		})
+
+		context("a declared process type is already taken", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "dotnet-execute.toml"), []byte("[[processes]]\ntype = \"migrate\"\ncommand = \"{{app}} migrate\"\n\n[[processes]]\ntype = \"migrate\"\ncommand = \"{{app}} --migrate\"\n"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`failed to add process type "migrate": a process of that type already exists`))
+			})
+		})
+
+		context("error when checking for existence of dll file", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
+						AppName:    "myapp",
+						Executable: false,
+					},
+				}
+				Expect(os.Chmod(workingDir, 0000)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.Chmod(workingDir, os.ModePerm)).To(Succeed())
+			})
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("permission denied")))
+			})
+		})
+
+		context("neither executable nor dll file are present (no entrypoint is found)", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
+						AppName:    "myapp",
+						Executable: false,
+					},
+				}
+				files, err := filepath.Glob(filepath.Join(workingDir, "*.dll"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(files).To(BeEmpty())
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("no entrypoint [myapp.dll] found")))
+			})
+
+		})
+
+		context("the apphost was published for another architecture", func() {
+			it.Before(func() {
+				t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName:    "my.app",
+						Executable: true,
+						Arch:       "arm64",
+					},
+				}
+			})
+
+			it("returns an error naming the RID", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("the apphost of app 'my.app' was published for linux-arm64, but the image is built for linux-x64: publish the app with --runtime linux-x64, or build the image for arm64"))
+			})
+		})
+
+		context("the native libraries were only published for another architecture", func() {
+			it.Before(func() {
+				t.Setenv("CNB_TARGET_ARCH", "arm64")
+
+				Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", "linux-x64", "native"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", "linux-x64", "native", "libe_sqlite3.so"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, false), 0600)).To(Succeed())
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName:        "my.app",
+						RuntimeVersion: "8.0.0",
+					},
+				}
+			})
+
+			it("returns an error naming the RID", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("the native libraries of the app were only published for linux-x64, but the image is built for linux-arm64: publish the app with --runtime linux-arm64"))
+			})
+		})
+
+		context("a native library does not match the RID it is shipped for", func() {
+			it.Before(func() {
+				t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+				Expect(os.MkdirAll(filepath.Join(workingDir, "runtimes", "linux-x64", "native"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "runtimes", "linux-x64", "native", "libe_sqlite3.so"), elfExecutable(elf.EM_AARCH64, elf.ET_DYN, false), 0600)).To(Succeed())
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName:        "my.app",
+						RuntimeVersion: "8.0.0",
+					},
+				}
+			})
+
+			it("returns an error naming the RID", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(fmt.Sprintf("native library %s is built for arm64, but is shipped for linux-x64, which the image is built for: check the NuGet package that provides it", filepath.Join("runtimes", "linux-x64", "native", "libe_sqlite3.so"))))
+			})
+		})
+
+		context("the app was published for Windows", func() {
+			it.Before(func() {
+				t.Setenv("CNB_TARGET_ARCH", "amd64")
+
+				Expect(os.WriteFile(filepath.Join(workingDir, "my.app.deps.json"), []byte(`{
+  "runtimeTarget": {"name": ".NETCoreApp,Version=v8.0/win-x64"},
+  "libraries": {}
+}`), 0600)).To(Succeed())
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:    filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName: "my.app",
+					},
+				}
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError("app 'my.app' was published for win-x64, which cannot run on Linux: publish the app with --runtime linux-x64, or without --runtime"))
+			})
+		})
+
+		context("BP_DOTNET_FAIL_ON_SEVERITY is not a severity", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					FailOnSeverity: "urgent",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`unsupported BP_DOTNET_FAIL_ON_SEVERITY value: "urgent", must be one of low, moderate, high or critical`))
+			})
+		})
+
+		context("the advisory database is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "advisories.json"), []byte(`%%%`), 0600)).To(Succeed())
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "my.app.runtimeconfig.json"),
+						AppName:    "my.app",
+						Executable: true,
+					},
+				}
+
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					AdvisoryDB: filepath.Join(workingDir, "advisories.json"),
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse advisory database")))
+			})
+		})
+
+		context("BP_DOTNET_SBOM_PATHS points outside of the app directory", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					SBOMPaths: "../other-app",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`invalid BP_DOTNET_SBOM_PATHS entry "../other-app": must be a path inside the app directory`))
+			})
+		})
+
+		context("BP_DOTNET_SBOM_EXCLUDE contains an invalid pattern", func() {
+			it.Before(func() {
+				build = dotnetexecute.Build(dotnetexecute.Configuration{
+					SBOMExclude: "wwwroot:[fixtures",
+				}, configParser, sbomGenerator, logger, chronos.DefaultClock)
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(`invalid BP_DOTNET_SBOM_EXCLUDE pattern "[fixtures"`))
+			})
+		})
+
+		context("when generating the SBOM returns an error", func() {
+			it.Before(func() {
+				sbomGenerator.GenerateCall.Returns.Error = errors.New("failed to generate SBOM")
+
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
+						AppName:    "myapp",
+						Executable: true,
+					},
+				}
+			})
+
+			it("returns an error", func() {
+				_, err := build(packit.BuildContext{
+					WorkingDir: workingDir,
+					CNBPath:    cnbDir,
+					Stack:      "some-stack",
+					BuildpackInfo: packit.BuildpackInfo{
+						Name:    "Some Buildpack",
+						Version: "some-version",
+					},
+					Plan: packit.BuildpackPlan{
+						Entries: []packit.BuildpackPlanEntry{},
+					},
+					Layers: packit.Layers{Path: layersDir},
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to generate SBOM")))
+			})
+		})
+
+		context("when formatting the SBOM returns an error", func() {
+			it.Before(func() {
+				configParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:       filepath.Join(workingDir, "myapp.runtimeconfig.json"),
+						AppName:    "myapp",
+						Executable: true,
+					},
 				}
 			})
 
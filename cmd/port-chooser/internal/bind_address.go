@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// BindAddress is the launch-time variable that sets the address the app
+// listens on, overriding the detected one.
+const BindAddress = "BPL_DOTNET_BIND_ADDRESS"
+
+// The sources the address families of the container are detected from. They
+// are variables so that tests can replace them.
+var (
+	inet6Path      = "/proc/net/if_inet6"
+	interfaceAddrs = net.InterfaceAddrs
+)
+
+// ifInet6ScopeGlobal is the scope of the globally routable addresses listed
+// in /proc/net/if_inet6.
+const ifInet6ScopeGlobal = "00"
+
+// detectBindHost returns the host the app should listen on: [::] when the
+// container has a routable IPv6 address, which Kestrel binds dual-mode so
+// that it serves IPv4 as well, and 0.0.0.0 otherwise.
+func detectBindHost() (string, error) {
+	ipv6, err := hasIPv6()
+	if err != nil {
+		return "", err
+	}
+
+	if ipv6 {
+		return "[::]", nil
+	}
+
+	return "0.0.0.0", nil
+}
+
+// hasIPv6 reports whether IPv6 is enabled, which is when /proc/net/if_inet6
+// exists, and a globally routable IPv6 address is assigned to one of the
+// interfaces. Loopback and link-local addresses, which are present even in
+// IPv4-only clusters, do not count.
+func hasIPv6() (bool, error) {
+	file, err := os.Open(inet6Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to detect IPv6 support: %w", err)
+	}
+	defer file.Close()
+
+	// Each line is: address, interface index, prefix length, scope, flags and
+	// interface name
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && fields[3] == ifInet6ScopeGlobal {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to detect IPv6 support: %w", err)
+	}
+
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return false, fmt.Errorf("failed to detect IPv6 support: %w", err)
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.To4() != nil {
+			continue
+		}
+
+		if !ipNet.IP.IsLoopback() && !ipNet.IP.IsLinkLocalUnicast() {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// parseBindAddress returns the URL host for the given BPL_DOTNET_BIND_ADDRESS
+// value, which is an IP address, a host name, or the + or * wildcard.
+func parseBindAddress(value string) (string, error) {
+	address := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	switch ip := net.ParseIP(address); {
+	case address == "+", address == "*":
+		return address, nil
+	case ip != nil && ip.To4() == nil:
+		return fmt.Sprintf("[%s]", address), nil
+	case ip != nil:
+		return address, nil
+	case hostNamePattern.MatchString(value):
+		return value, nil
+	}
+
+	return "", fmt.Errorf("%s=%q is neither an IP address, a host name nor a wildcard (+ or *)", BindAddress, value)
+}
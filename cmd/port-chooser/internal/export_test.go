@@ -0,0 +1,14 @@
+package internal
+
+import "net"
+
+// SetAddressSources replaces the sources the address families of the
+// container are detected from, and returns a function that restores them.
+func SetAddressSources(path string, addrs func() ([]net.Addr, error)) func() {
+	originalPath, originalAddrs := inet6Path, interfaceAddrs
+	inet6Path, interfaceAddrs = path, addrs
+
+	return func() {
+		inet6Path, interfaceAddrs = originalPath, originalAddrs
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ListenSocket is the launch-time variable that makes the app listen on a
+// Unix domain socket at the given path rather than on a TCP port.
+const ListenSocket = "BPL_DOTNET_LISTEN_SOCKET"
+
+// parseListenSocket validates the BPL_DOTNET_LISTEN_SOCKET value.
+func parseListenSocket(value string) (string, error) {
+	if !filepath.IsAbs(value) {
+		return "", fmt.Errorf("%s=%q is not an absolute path", ListenSocket, value)
+	}
+
+	return filepath.Clean(value), nil
+}
+
+// prepareListenSocket makes sure that Kestrel can create the socket at the
+// given path. It runs at launch time as the user the app runs as, so the
+// directory of the socket must be writable by that user, or be created under
+// a directory that is, such as a mounted volume. A directory it creates gets
+// mode 0755. Other users connecting to the socket also need write permission
+// on the socket file itself, which Kestrel creates according to the umask of
+// the app.
+func prepareListenSocket(path string) error {
+	dir := filepath.Dir(path)
+
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create the directory of %s: %w", ListenSocket, err)
+		}
+
+		// The umask may have taken permissions away
+		err = os.Chmod(dir, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create the directory of %s: %w", ListenSocket, err)
+		}
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to access the directory of %s: %w", ListenSocket, err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("failed to create the directory of %s: %s is not a directory", ListenSocket, dir)
+	}
+
+	// Creating a file is the only reliable way to tell whether Kestrel will be
+	// able to create the socket, whatever the owner and mode of the directory
+	probe, err := os.CreateTemp(dir, ".port-chooser-*")
+	if err != nil {
+		return fmt.Errorf("the directory of %s is not writable: %w", ListenSocket, err)
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
@@ -2,8 +2,10 @@ package internal
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -12,31 +14,262 @@ const (
 	// 5.0: https://docs.microsoft.com/en-us/aspnet/core/fundamentals/host/web-host?view=aspnetcore-5.0#server-urls-1
 	// 3.1: https://docs.microsoft.com/en-us/aspnet/core/fundamentals/host/web-host?view=aspnetcore-3.1#server-urls-2
 	AspNetCoreUrls = "ASPNETCORE_URLS"
+
+	// AspNetCoreHttpPorts and AspNetCoreHttpsPorts list the ports to listen on
+	// on all interfaces, from ASP.NET Core 8.0 onwards:
+	// https://learn.microsoft.com/en-us/aspnet/core/fundamentals/servers/kestrel/endpoints?view=aspnetcore-8.0#configure-endpoints
+	AspNetCoreHttpPorts  = "ASPNETCORE_HTTP_PORTS"
+	AspNetCoreHttpsPorts = "ASPNETCORE_HTTPS_PORTS"
+
+	// DotnetUrls, DotnetHttpPorts and DotnetHttpsPorts are the DOTNET_
+	// prefixed equivalents, read by the generic host.
+	DotnetUrls       = "DOTNET_URLS"
+	DotnetHttpPorts  = "DOTNET_HTTP_PORTS"
+	DotnetHttpsPorts = "DOTNET_HTTPS_PORTS"
+
+	// StrictPort is the launch-time variable that makes invalid port
+	// configuration fatal rather than a warning.
+	StrictPort = "BPL_DOTNET_STRICT_PORT"
+
+	// FrameworkVersion is set by the buildpack to the version of the .NET
+	// framework the app targets.
+	FrameworkVersion = "BPI_DOTNET_FRAMEWORK_VERSION"
 )
 
+// endpointVariables are the environment variables through which the user can
+// tell the app where to listen.
+var endpointVariables = []string{
+	AspNetCoreUrls,
+	AspNetCoreHttpPorts,
+	AspNetCoreHttpsPorts,
+	DotnetUrls,
+	DotnetHttpPorts,
+	DotnetHttpsPorts,
+}
+
 // ChoosePort will choose a port for the .NET Core application.
-// If an environment variable `ASPNETCORE_URLS` already exists, no further action is taken.
+// If any of `ASPNETCORE_URLS`, `ASPNETCORE_HTTP_PORTS`,
+// `ASPNETCORE_HTTPS_PORTS`, `DOTNET_URLS`, `DOTNET_HTTP_PORTS` or
+// `DOTNET_HTTPS_PORTS` already exists, no further action is taken.
 // Otherwise, the `PORT` environment variable is chosen.
-// If neither `ASPNETCORE_URLS` nor `PORT` is defined, `8080` is chosen.
-func ChoosePort() (map[string]string, error) {
-	if _, hasUrl := os.LookupEnv(AspNetCoreUrls); hasUrl {
-		return map[string]string{}, nil
+// If `PORT` is not defined, `8080` is chosen.
+// Apps targeting .NET 8 or later, according to `BPI_DOTNET_FRAMEWORK_VERSION`,
+// are given the port through `ASPNETCORE_HTTP_PORTS`, other apps through
+// `ASPNETCORE_URLS`.
+//
+// When a `tls` or `kestrel-certificate` service binding is present, Kestrel is
+// configured to use its certificate, and `ASPNETCORE_URLS` gets an additional
+// https endpoint on the `BPL_DOTNET_HTTPS_PORT` port, `8443` by default.
+// Bindings that cannot be read are reported, and the app is served over http
+// only.
+//
+// The URLs listen on `[::]` when the container has IPv6 connectivity, and on
+// `0.0.0.0` otherwise, unless `BPL_DOTNET_BIND_ADDRESS` gives the address.
+// When the IPv6 connectivity cannot be detected, the URLs listen on `0.0.0.0`.
+// Setting it makes apps targeting .NET 8 or later use `ASPNETCORE_URLS` too.
+//
+// When `BPL_DOTNET_LISTEN_SOCKET` is set, the app listens on a Unix domain
+// socket at that path instead, whose directory is created if needed. When the
+// directory cannot be created or written to, the app listens on a port.
+//
+// Invalid values of these variables are reported to the given diagnostics
+// writer, and ignored where a default exists. When `BPL_DOTNET_STRICT_PORT`
+// is true, ChoosePort returns an error for them instead.
+func ChoosePort(diagnostics io.Writer) (map[string]string, error) {
+	envVars := map[string]string{}
+	var problems []portProblem
+
+	strict := false
+	if value, ok := os.LookupEnv(StrictPort); ok {
+		var err error
+		strict, err = strconv.ParseBool(value)
+		if err != nil {
+			problems = append(problems, portProblem{
+				err:         fmt.Errorf("%s=%q is not a boolean", StrictPort, value),
+				consequence: "treating it as false",
+			})
+		}
 	}
 
-	portForDotNet := 8080
+	// Service bindings of every type are loaded, so a malformed one that has
+	// nothing to do with TLS must not keep the app from starting
+	certificate, hasCertificate, err := findTLSCertificate()
+	if err != nil {
+		problems = append(problems, portProblem{
+			err:         fmt.Errorf("failed to look up a TLS certificate: %w", err),
+			consequence: "serving http only",
+		})
+	}
+
+	if _, ok := os.LookupEnv(KestrelCertificatePath); hasCertificate && !ok {
+		fmt.Printf("Setting %s=%s\n", KestrelCertificatePath, certificate.Path)
+		envVars[KestrelCertificatePath] = certificate.Path
+
+		if certificate.KeyPath != "" {
+			fmt.Printf("Setting %s=%s\n", KestrelCertificateKeyPath, certificate.KeyPath)
+			envVars[KestrelCertificateKeyPath] = certificate.KeyPath
+		}
+
+		if certificate.Password != "" {
+			fmt.Printf("Setting %s\n", KestrelCertificatePassword)
+			envVars[KestrelCertificatePassword] = certificate.Password
+		}
+	}
+
+	userEndpoints := false
+	for _, name := range endpointVariables {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		userEndpoints = true
 
-	if port, hasPort := os.LookupEnv("PORT"); hasPort {
-		if port, err := strconv.Atoi(port); err == nil {
+		var errs []error
+		switch name {
+		case AspNetCoreUrls, DotnetUrls:
+			errs = validateURLs(name, value)
+		default:
+			errs = validatePorts(name, value)
+		}
+
+		for _, err := range errs {
+			problems = append(problems, portProblem{err: err, consequence: "the app may fail to start"})
+		}
+	}
+
+	portForDotNet := 8080
+	if port, hasPort := os.LookupEnv("PORT"); hasPort && !userEndpoints {
+		port, err := parsePort("PORT", port)
+		if err != nil {
+			problems = append(problems, portProblem{err: err, consequence: "using 8080 instead"})
+		} else {
 			portForDotNet = port
 		}
 	}
 
-	url := fmt.Sprintf("http://0.0.0.0:%d", portForDotNet)
+	httpsPort := 8443
+	if port, ok := os.LookupEnv(HttpsPort); ok && hasCertificate && !userEndpoints {
+		port, err := parsePort(HttpsPort, port)
+		if err != nil {
+			problems = append(problems, portProblem{err: err, consequence: "using 8443 instead"})
+		} else {
+			httpsPort = port
+		}
+	}
 
-	fmt.Printf("Setting ASPNETCORE_URLS=%s\n", url)
+	var host string
+	if address, ok := os.LookupEnv(BindAddress); ok && !userEndpoints {
+		host, err = parseBindAddress(address)
+		if err != nil {
+			problems = append(problems, portProblem{err: err, consequence: "detecting the address instead"})
+		}
+	}
+
+	var socket string
+	if path, ok := os.LookupEnv(ListenSocket); ok && !userEndpoints {
+		socket, err = parseListenSocket(path)
+		if err == nil {
+			err = prepareListenSocket(socket)
+		}
+		if err != nil {
+			problems = append(problems, portProblem{err: err, consequence: "listening on a port instead"})
+			socket = ""
+		}
+	}
 
-	envVars := map[string]string{
-		AspNetCoreUrls: url,
+	// ASPNETCORE_HTTP_PORTS listens on every address of both IP stacks, so the
+	// address only matters when it is set or for the other variables
+	explicitHost := host != ""
+	httpPorts := supportsHttpPorts(os.Getenv(FrameworkVersion)) && !explicitHost && !hasCertificate
+	if !explicitHost && !httpPorts && !userEndpoints && socket == "" {
+		host, err = detectBindHost()
+		if err != nil {
+			problems = append(problems, portProblem{err: err, consequence: "listening on 0.0.0.0"})
+			host = "0.0.0.0"
+		}
 	}
+
+	if err := reportProblems(diagnostics, problems, strict); err != nil {
+		return nil, err
+	}
+
+	if userEndpoints {
+		return envVars, nil
+	}
+
+	if socket != "" {
+		url := fmt.Sprintf("http://unix:%s", socket)
+
+		fmt.Printf("Setting ASPNETCORE_URLS=%s\n", url)
+
+		envVars[AspNetCoreUrls] = url
+		return envVars, nil
+	}
+
+	if hasCertificate {
+		urls := fmt.Sprintf("http://%s:%d;https://%s:%d", host, portForDotNet, host, httpsPort)
+
+		fmt.Printf("Setting ASPNETCORE_URLS=%s\n", urls)
+
+		envVars[AspNetCoreUrls] = urls
+		return envVars, nil
+	}
+
+	if httpPorts {
+		fmt.Printf("Setting %s=%d\n", AspNetCoreHttpPorts, portForDotNet)
+
+		envVars[AspNetCoreHttpPorts] = strconv.Itoa(portForDotNet)
+		return envVars, nil
+	}
+
+	url := fmt.Sprintf("http://%s:%d", host, portForDotNet)
+
+	fmt.Printf("Setting ASPNETCORE_URLS=%s\n", url)
+
+	envVars[AspNetCoreUrls] = url
 	return envVars, nil
 }
+
+// portProblem is an invalid value found by ChoosePort, along with what
+// happens when it is not fatal.
+type portProblem struct {
+	err         error
+	consequence string
+}
+
+// reportProblems writes the given problems to the diagnostics writer as
+// warnings, or returns them as an error in strict mode.
+func reportProblems(diagnostics io.Writer, problems []portProblem, strict bool) error {
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if strict {
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.err.Error())
+		}
+
+		return fmt.Errorf("invalid port configuration: %s", strings.Join(messages, "; "))
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(diagnostics, "Warning: %s, %s\n", problem.err, problem.consequence)
+	}
+	fmt.Fprintf(diagnostics, "Set %s=true to make invalid port configuration fatal\n", StrictPort)
+
+	return nil
+}
+
+// supportsHttpPorts reports whether the given framework version is .NET 8 or
+// later. Unknown versions are assumed not to be, as ASPNETCORE_URLS works on
+// every version.
+func supportsHttpPorts(version string) bool {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	number, err := strconv.Atoi(major)
+	if err != nil {
+		return false
+	}
+
+	return number >= 8
+}
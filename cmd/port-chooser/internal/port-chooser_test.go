@@ -1,7 +1,12 @@
 package internal_test
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/paketo-buildpacks/dotnet-execute/cmd/port-chooser/internal"
@@ -15,22 +20,75 @@ import (
 func testPortChooser(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
+
+		diagnostics *bytes.Buffer
+		procDir     string
+		interfaces  []net.Addr
+		addrsErr    error
+		restore     func()
 	)
 
+	unsetEnv := func() {
+		for _, name := range []string{
+			"PORT",
+			"ASPNETCORE_URLS",
+			"ASPNETCORE_HTTP_PORTS",
+			"ASPNETCORE_HTTPS_PORTS",
+			"DOTNET_URLS",
+			"DOTNET_HTTP_PORTS",
+			"DOTNET_HTTPS_PORTS",
+			"BPI_DOTNET_FRAMEWORK_VERSION",
+			"BPL_DOTNET_HTTPS_PORT",
+			"SERVICE_BINDING_ROOT",
+			"CNB_BINDINGS",
+			"ASPNETCORE_Kestrel__Certificates__Default__Path",
+			"BPL_DOTNET_STRICT_PORT",
+			"BPL_DOTNET_BIND_ADDRESS",
+			"BPL_DOTNET_LISTEN_SOCKET",
+		} {
+			Expect(os.Unsetenv(name)).NotTo(HaveOccurred())
+		}
+	}
+
 	it.Before(func() {
-		Expect(os.Unsetenv("PORT")).NotTo(HaveOccurred())
-		Expect(os.Unsetenv("ASPNETCORE_URLS")).NotTo(HaveOccurred())
+		unsetEnv()
+
+		diagnostics = bytes.NewBuffer(nil)
+
+		// An IPv4-only container, with IPv6 enabled in the kernel
+		var err error
+		procDir, err = os.MkdirTemp("", "proc")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(procDir, "if_inet6"), []byte(`fe8000000000000000fc00fffe000001 04 40 20 80     eth0
+00000000000000000000000000000001 01 80 10 80       lo
+`), 0600)).To(Succeed())
+
+		interfaces = []net.Addr{
+			&net.IPNet{IP: net.ParseIP("127.0.0.1"), Mask: net.CIDRMask(8, 32)},
+			&net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)},
+			&net.IPNet{IP: net.ParseIP("::1"), Mask: net.CIDRMask(128, 128)},
+			&net.IPNet{IP: net.ParseIP("fe80::fc00:ff:fe00:1"), Mask: net.CIDRMask(64, 128)},
+		}
+
+		addrsErr = nil
+
+		restore = internal.SetAddressSources(filepath.Join(procDir, "if_inet6"), func() ([]net.Addr, error) {
+			return interfaces, addrsErr
+		})
 	})
 
 	it.After(func() {
-		Expect(os.Unsetenv("PORT")).NotTo(HaveOccurred())
-		Expect(os.Unsetenv("ASPNETCORE_URLS")).NotTo(HaveOccurred())
+		unsetEnv()
+
+		restore()
+		Expect(os.RemoveAll(procDir)).To(Succeed())
 	})
 
 	context(`when ASPNETCORE_URLS is not set`, func() {
 		context(`when PORT is not set`, func() {
 			it(`will set ASPNETCORE_URLS to http://0.0.0.0:8080`, func() {
-				envVars, err := internal.ChoosePort()
+				envVars, err := internal.ChoosePort(diagnostics)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(envVars).To(Equal(map[string]string{
 					"ASPNETCORE_URLS": "http://0.0.0.0:8080",
@@ -44,7 +102,7 @@ func testPortChooser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it(`will set ASPNETCORE_URLS to http://0.0.0.0:9876`, func() {
-				envVars, err := internal.ChoosePort()
+				envVars, err := internal.ChoosePort(diagnostics)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(envVars).To(Equal(map[string]string{
 					"ASPNETCORE_URLS": "http://0.0.0.0:9876",
@@ -57,12 +115,448 @@ func testPortChooser(t *testing.T, context spec.G, it spec.S) {
 				Expect(os.Setenv("PORT", "hi")).NotTo(HaveOccurred())
 			})
 
-			it(`will set ASPNETCORE_URLS to http://0.0.0.0:8080`, func() {
-				envVars, err := internal.ChoosePort()
+			it(`will set ASPNETCORE_URLS to http://0.0.0.0:8080 and report the invalid value`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:8080",
+				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring(`Warning: PORT="hi" is not a port number between 1 and 65535, using 8080 instead`))
+				Expect(diagnostics.String()).To(ContainSubstring("Set BPL_DOTNET_STRICT_PORT=true to make invalid port configuration fatal"))
+			})
+
+			context(`when BPL_DOTNET_STRICT_PORT is true`, func() {
+				it.Before(func() {
+					Expect(os.Setenv("BPL_DOTNET_STRICT_PORT", "true")).NotTo(HaveOccurred())
+				})
+
+				it(`returns an error`, func() {
+					_, err := internal.ChoosePort(diagnostics)
+					Expect(err).To(MatchError(`invalid port configuration: PORT="hi" is not a port number between 1 and 65535`))
+				})
+			})
+		})
+
+		context(`when PORT is out of range`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("PORT", "70000")).NotTo(HaveOccurred())
+			})
+
+			it(`will set ASPNETCORE_URLS to http://0.0.0.0:8080 and report the invalid value`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:8080",
+				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring(`Warning: PORT="70000" is not a port number between 1 and 65535, using 8080 instead`))
+			})
+		})
+	})
+
+	context(`when ASPNETCORE_URLS is set to valid URLs`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("ASPNETCORE_URLS", "http://+:80;https://*:443; http://[::]:8080;http://localhost;http://10.0.0.1:5000/;http://unix:/tmp/app.sock")).NotTo(HaveOccurred())
+		})
+
+		it(`reports nothing`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(BeEmpty())
+			Expect(diagnostics.String()).To(BeEmpty())
+		})
+	})
+
+	context(`when ASPNETCORE_URLS is set to invalid URLs`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("ASPNETCORE_URLS", "ftp://+:21;localhost:80;http://*.example.com;http://[::1:80;http://localhost:0;http://unix:tmp/app.sock")).NotTo(HaveOccurred())
+		})
+
+		it(`reports every one of them and leaves ASPNETCORE_URLS in place`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(BeEmpty())
+
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "ftp://+:21" has scheme "ftp", expected http or https, the app may fail to start`))
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "localhost:80" has no scheme, expected http:// or https://`))
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "http://*.example.com" has host "*.example.com", but the + and * wildcards must make up the whole host`))
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "http://[::1:80" has an unterminated IPv6 address`))
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "http://localhost:0" has port "0", expected a number between 1 and 65535`))
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_URLS entry "http://unix:tmp/app.sock" has socket path "tmp/app.sock", expected an absolute path`))
+		})
+
+		context(`when BPL_DOTNET_STRICT_PORT is true`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("BPL_DOTNET_STRICT_PORT", "true")).NotTo(HaveOccurred())
+			})
+
+			it(`returns an error`, func() {
+				_, err := internal.ChoosePort(diagnostics)
+				Expect(err).To(MatchError(ContainSubstring(`invalid port configuration: ASPNETCORE_URLS entry "ftp://+:21" has scheme "ftp", expected http or https;`)))
+			})
+		})
+	})
+
+	context(`when ASPNETCORE_HTTP_PORTS is set to an invalid port`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("ASPNETCORE_HTTP_PORTS", "8080;http")).NotTo(HaveOccurred())
+		})
+
+		it(`reports it`, func() {
+			_, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diagnostics.String()).To(ContainSubstring(`Warning: ASPNETCORE_HTTP_PORTS entry "http" is not a port number between 1 and 65535`))
+		})
+	})
+
+	context(`when the container has a routable IPv6 address`, func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(procDir, "if_inet6"), []byte(`fd000000000000000000000000000002 04 40 00 82     eth0
+fe8000000000000000fc00fffe000001 04 40 20 80     eth0
+00000000000000000000000000000001 01 80 10 80       lo
+`), 0600)).To(Succeed())
+		})
+
+		it(`will listen on [::]`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://[::]:8080",
+			}))
+		})
+	})
+
+	context(`when only the interfaces have a routable IPv6 address`, func() {
+		it.Before(func() {
+			interfaces = append(interfaces, &net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(64, 128)})
+		})
+
+		it(`will listen on [::]`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://[::]:8080",
+			}))
+		})
+
+		context(`when IPv6 is disabled in the kernel`, func() {
+			it.Before(func() {
+				Expect(os.Remove(filepath.Join(procDir, "if_inet6"))).To(Succeed())
+			})
+
+			it(`will listen on 0.0.0.0`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:8080",
+				}))
+			})
+		})
+	})
+
+	context(`when the IPv6 connectivity cannot be detected`, func() {
+		it.Before(func() {
+			addrsErr = errors.New("some-error")
+		})
+
+		it(`reports it and will listen on 0.0.0.0`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://0.0.0.0:8080",
+			}))
+
+			Expect(diagnostics.String()).To(ContainSubstring("Warning: failed to detect IPv6 support: some-error, listening on 0.0.0.0"))
+		})
+
+		context(`when BPL_DOTNET_STRICT_PORT is true`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("BPL_DOTNET_STRICT_PORT", "true")).NotTo(HaveOccurred())
+			})
+
+			it(`returns an error`, func() {
+				_, err := internal.ChoosePort(diagnostics)
+				Expect(err).To(MatchError("invalid port configuration: failed to detect IPv6 support: some-error"))
+			})
+		})
+	})
+
+	context(`when BPL_DOTNET_BIND_ADDRESS is set`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("BPL_DOTNET_BIND_ADDRESS", "fd00::2")).NotTo(HaveOccurred())
+			Expect(os.Setenv("BPI_DOTNET_FRAMEWORK_VERSION", "8.0.1")).NotTo(HaveOccurred())
+		})
+
+		it(`will listen on that address, even for .NET 8 apps`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://[fd00::2]:8080",
+			}))
+		})
+
+		context(`to an invalid address`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("BPL_DOTNET_BIND_ADDRESS", "not an address")).NotTo(HaveOccurred())
+				Expect(os.Unsetenv("BPI_DOTNET_FRAMEWORK_VERSION")).NotTo(HaveOccurred())
+			})
+
+			it(`reports it and detects the address instead`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(envVars).To(Equal(map[string]string{
 					"ASPNETCORE_URLS": "http://0.0.0.0:8080",
 				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring(`Warning: BPL_DOTNET_BIND_ADDRESS="not an address" is neither an IP address, a host name nor a wildcard (+ or *), detecting the address instead`))
+			})
+		})
+	})
+
+	context(`when BPL_DOTNET_LISTEN_SOCKET is set`, func() {
+		var socketDir string
+
+		it.Before(func() {
+			var err error
+			socketDir, err = os.MkdirTemp("", "sockets")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Setenv("BPL_DOTNET_LISTEN_SOCKET", filepath.Join(socketDir, "run", "app.sock"))).NotTo(HaveOccurred())
+			Expect(os.Setenv("PORT", "9876")).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(socketDir)).To(Succeed())
+		})
+
+		it(`will listen on the socket and create its directory`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": fmt.Sprintf("http://unix:%s", filepath.Join(socketDir, "run", "app.sock")),
+			}))
+
+			info, err := os.Stat(filepath.Join(socketDir, "run"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.IsDir()).To(BeTrue())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+		})
+
+		context(`to a relative path`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("BPL_DOTNET_LISTEN_SOCKET", "run/app.sock")).NotTo(HaveOccurred())
+			})
+
+			it(`reports it and listens on a port instead`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:9876",
+				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring(`Warning: BPL_DOTNET_LISTEN_SOCKET="run/app.sock" is not an absolute path, listening on a port instead`))
+			})
+		})
+
+		context(`when the socket directory cannot be created`, func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(socketDir, "run"), nil, 0600)).To(Succeed())
+			})
+
+			it(`reports it and listens on a port instead`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:9876",
+				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring(fmt.Sprintf("Warning: failed to create the directory of BPL_DOTNET_LISTEN_SOCKET: %s is not a directory, listening on a port instead", filepath.Join(socketDir, "run"))))
+			})
+
+			context(`when BPL_DOTNET_STRICT_PORT is true`, func() {
+				it.Before(func() {
+					Expect(os.Setenv("BPL_DOTNET_STRICT_PORT", "true")).NotTo(HaveOccurred())
+				})
+
+				it(`returns an error`, func() {
+					_, err := internal.ChoosePort(diagnostics)
+					Expect(err).To(MatchError(ContainSubstring("invalid port configuration: failed to create the directory of BPL_DOTNET_LISTEN_SOCKET")))
+				})
+			})
+		})
+
+		context(`when the socket directory already exists`, func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(socketDir, "run"), 0700)).To(Succeed())
+			})
+
+			it(`will listen on the socket and leave the directory as it is`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": fmt.Sprintf("http://unix:%s", filepath.Join(socketDir, "run", "app.sock")),
+				}))
+
+				entries, err := os.ReadDir(filepath.Join(socketDir, "run"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+
+				info, err := os.Stat(filepath.Join(socketDir, "run"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+			})
+		})
+	})
+
+	context(`when the app targets .NET 8 or later`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("BPI_DOTNET_FRAMEWORK_VERSION", "8.0.1")).NotTo(HaveOccurred())
+			Expect(os.Setenv("PORT", "9876")).NotTo(HaveOccurred())
+		})
+
+		it(`will set ASPNETCORE_HTTP_PORTS`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_HTTP_PORTS": "9876",
+			}))
+		})
+	})
+
+	context(`when the app targets a .NET version before 8`, func() {
+		it.Before(func() {
+			Expect(os.Setenv("BPI_DOTNET_FRAMEWORK_VERSION", "6.0.25")).NotTo(HaveOccurred())
+		})
+
+		it(`will set ASPNETCORE_URLS`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://0.0.0.0:8080",
+			}))
+		})
+	})
+
+	for _, name := range []string{
+		"ASPNETCORE_HTTP_PORTS",
+		"ASPNETCORE_HTTPS_PORTS",
+		"DOTNET_URLS",
+		"DOTNET_HTTP_PORTS",
+		"DOTNET_HTTPS_PORTS",
+	} {
+		name := name
+
+		context(fmt.Sprintf(`when %s is set`, name), func() {
+			it.Before(func() {
+				Expect(os.Setenv(name, "5000")).NotTo(HaveOccurred())
+				Expect(os.Setenv("BPI_DOTNET_FRAMEWORK_VERSION", "8.0.1")).NotTo(HaveOccurred())
+			})
+
+			it(fmt.Sprintf(`will leave %s in place`, name), func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{}))
+				Expect(os.Getenv(name)).To(Equal("5000"))
+			})
+		})
+	}
+
+	context(`when there is a TLS service binding`, func() {
+		var bindingRoot string
+
+		it.Before(func() {
+			var err error
+			bindingRoot, err = os.MkdirTemp("", "bindings")
+			Expect(err).NotTo(HaveOccurred())
+
+			bindingDir := filepath.Join(bindingRoot, "some-certificate")
+			Expect(os.MkdirAll(bindingDir, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(bindingDir, "type"), []byte("tls"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(bindingDir, "tls.crt"), []byte("some-certificate"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(bindingDir, "tls.key"), []byte("some-key"), 0600)).To(Succeed())
+
+			Expect(os.Setenv("SERVICE_BINDING_ROOT", bindingRoot)).NotTo(HaveOccurred())
+			Expect(os.Setenv("PORT", "9876")).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(bindingRoot)).To(Succeed())
+		})
+
+		it(`will add an https endpoint that uses the certificate`, func() {
+			envVars, err := internal.ChoosePort(diagnostics)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envVars).To(Equal(map[string]string{
+				"ASPNETCORE_URLS": "http://0.0.0.0:9876;https://0.0.0.0:8443",
+				"ASPNETCORE_Kestrel__Certificates__Default__Path":    filepath.Join(bindingRoot, "some-certificate", "tls.crt"),
+				"ASPNETCORE_Kestrel__Certificates__Default__KeyPath": filepath.Join(bindingRoot, "some-certificate", "tls.key"),
+			}))
+		})
+
+		context(`when the binding is a kestrel-certificate one with a password`, func() {
+			it.Before(func() {
+				bindingDir := filepath.Join(bindingRoot, "some-certificate")
+				Expect(os.RemoveAll(bindingDir)).To(Succeed())
+				Expect(os.MkdirAll(bindingDir, os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(bindingDir, "type"), []byte("kestrel-certificate"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(bindingDir, "app.pfx"), []byte("some-certificate"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(bindingDir, "password"), []byte("some-password\n"), 0600)).To(Succeed())
+
+				Expect(os.Setenv("BPL_DOTNET_HTTPS_PORT", "9443")).NotTo(HaveOccurred())
+			})
+
+			it(`will use the certificate and password on the configured port`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:9876;https://0.0.0.0:9443",
+					"ASPNETCORE_Kestrel__Certificates__Default__Path":     filepath.Join(bindingRoot, "some-certificate", "app.pfx"),
+					"ASPNETCORE_Kestrel__Certificates__Default__Password": "some-password",
+				}))
+			})
+		})
+
+		context(`when another binding is malformed`, func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(bindingRoot, "some-broken-binding"), os.ModePerm)).To(Succeed())
+			})
+
+			it(`reports it and will only listen over http`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_URLS": "http://0.0.0.0:9876",
+				}))
+
+				Expect(diagnostics.String()).To(ContainSubstring("Warning: failed to look up a TLS certificate: "))
+				Expect(diagnostics.String()).To(ContainSubstring(", serving http only"))
+			})
+
+			context(`when BPL_DOTNET_STRICT_PORT is true`, func() {
+				it.Before(func() {
+					Expect(os.Setenv("BPL_DOTNET_STRICT_PORT", "true")).NotTo(HaveOccurred())
+				})
+
+				it(`returns an error`, func() {
+					_, err := internal.ChoosePort(diagnostics)
+					Expect(err).To(MatchError(ContainSubstring("invalid port configuration: failed to look up a TLS certificate: ")))
+				})
+			})
+		})
+
+		context(`when the endpoints are set by the user`, func() {
+			it.Before(func() {
+				Expect(os.Setenv("ASPNETCORE_HTTPS_PORTS", "5001")).NotTo(HaveOccurred())
+			})
+
+			it(`will only configure the certificate`, func() {
+				envVars, err := internal.ChoosePort(diagnostics)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(envVars).To(Equal(map[string]string{
+					"ASPNETCORE_Kestrel__Certificates__Default__Path":    filepath.Join(bindingRoot, "some-certificate", "tls.crt"),
+					"ASPNETCORE_Kestrel__Certificates__Default__KeyPath": filepath.Join(bindingRoot, "some-certificate", "tls.key"),
+				}))
 			})
 		})
 	})
@@ -81,7 +575,7 @@ func testPortChooser(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it(`will leave ASPNETCORE_URLS in place`, func() {
-			envVars, err := internal.ChoosePort()
+			envVars, err := internal.ChoosePort(diagnostics)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(envVars).To(Equal(map[string]string{}))
 			Expect(os.Getenv("ASPNETCORE_URLS")).To(Equal(aspNetCoreUrl))
@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+const (
+	// The Kestrel settings for the default certificate used by https
+	// endpoints:
+	// https://learn.microsoft.com/en-us/aspnet/core/fundamentals/servers/kestrel/endpoints?view=aspnetcore-8.0#configure-https-in-appsettingsjson
+	KestrelCertificatePath     = "ASPNETCORE_Kestrel__Certificates__Default__Path"
+	KestrelCertificateKeyPath  = "ASPNETCORE_Kestrel__Certificates__Default__KeyPath"
+	KestrelCertificatePassword = "ASPNETCORE_Kestrel__Certificates__Default__Password"
+
+	// HttpsPort is the launch-time variable that sets the port of the https
+	// endpoint, 8443 by default.
+	HttpsPort = "BPL_DOTNET_HTTPS_PORT"
+)
+
+// tlsBindingTypes are the types of the service bindings that provide a
+// certificate, in order of preference.
+var tlsBindingTypes = []string{"kestrel-certificate", "tls"}
+
+// The entries of a TLS binding that hold the certificate, its private key and
+// the password of either, in order of preference. Kubernetes TLS secrets use
+// tls.crt and tls.key.
+var (
+	certificateEntries = []string{"tls.crt", "certificate.pfx", "certificate.pem", "cert.pem"}
+	keyEntries         = []string{"tls.key", "certificate.key", "key.pem"}
+	passwordEntries    = []string{"password"}
+)
+
+// tlsCertificate is the certificate Kestrel is configured with.
+type tlsCertificate struct {
+	Path     string
+	KeyPath  string
+	Password string
+}
+
+// findTLSCertificate returns the certificate of the first tls or
+// kestrel-certificate service binding, if there is one.
+func findTLSCertificate() (tlsCertificate, bool, error) {
+	// Without a binding root, bindings would be looked up relative to the
+	// working directory of the app
+	if os.Getenv("SERVICE_BINDING_ROOT") == "" && os.Getenv("CNB_BINDINGS") == "" {
+		return tlsCertificate{}, false, nil
+	}
+
+	resolver := servicebindings.NewResolver()
+	for _, typ := range tlsBindingTypes {
+		bindings, err := resolver.Resolve(typ, "", "")
+		if err != nil {
+			return tlsCertificate{}, false, err
+		}
+
+		sort.Slice(bindings, func(i, j int) bool {
+			return bindings[i].Name < bindings[j].Name
+		})
+
+		for _, binding := range bindings {
+			certificate := tlsCertificate{
+				Path:    bindingEntry(binding, certificateEntries, ".pfx"),
+				KeyPath: bindingEntry(binding, keyEntries, ""),
+			}
+			if certificate.Path == "" {
+				continue
+			}
+
+			if path := bindingEntry(binding, passwordEntries, ""); path != "" {
+				password, err := os.ReadFile(path)
+				if err != nil {
+					return tlsCertificate{}, false, err
+				}
+				certificate.Password = strings.TrimSpace(string(password))
+			}
+
+			return certificate, true, nil
+		}
+	}
+
+	return tlsCertificate{}, false, nil
+}
+
+// bindingEntry returns the path of the first of the given entries the binding
+// has, falling back to the first entry with the given suffix.
+func bindingEntry(binding servicebindings.Binding, names []string, suffix string) string {
+	for _, name := range names {
+		if _, ok := binding.Entries[name]; ok {
+			return filepath.Join(binding.Path, name)
+		}
+	}
+
+	if suffix == "" {
+		return ""
+	}
+
+	var matches []string
+	for name := range binding.Entries {
+		if strings.HasSuffix(name, suffix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return filepath.Join(binding.Path, matches[0])
+}
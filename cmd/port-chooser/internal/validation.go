@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hostNamePattern matches a DNS host name made of letters, digits and
+// hyphens.
+var hostNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+// parsePort parses a TCP port number given through the named environment
+// variable.
+func parsePort(name, value string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%s=%q is not a port number between 1 and 65535", name, value)
+	}
+
+	return port, nil
+}
+
+// validatePorts validates a semicolon-separated list of ports, as taken by
+// ASPNETCORE_HTTP_PORTS and the like.
+func validatePorts(name, value string) []error {
+	var problems []error
+	for _, port := range strings.Split(value, ";") {
+		if strings.TrimSpace(port) == "" {
+			continue
+		}
+
+		if _, err := parsePort(name, port); err != nil {
+			problems = append(problems, fmt.Errorf("%s entry %q is not a port number between 1 and 65535", name, port))
+		}
+	}
+
+	return problems
+}
+
+// validateURLs validates a semicolon-separated list of URLs, as taken by
+// ASPNETCORE_URLS and DOTNET_URLS. See
+// https://learn.microsoft.com/en-us/aspnet/core/fundamentals/servers/kestrel/endpoints#configure-endpoints
+// for the forms Kestrel accepts.
+func validateURLs(name, value string) []error {
+	var problems []error
+	for _, url := range strings.Split(value, ";") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+
+		if err := validateURL(url); err != nil {
+			problems = append(problems, fmt.Errorf("%s entry %q %w", name, url, err))
+		}
+	}
+
+	return problems
+}
+
+func validateURL(url string) error {
+	scheme, rest, found := strings.Cut(url, "://")
+	if !found {
+		return fmt.Errorf("has no scheme, expected http:// or https://")
+	}
+
+	if !strings.EqualFold(scheme, "http") && !strings.EqualFold(scheme, "https") {
+		return fmt.Errorf("has scheme %q, expected http or https", scheme)
+	}
+
+	// Unix domain sockets are given as http://unix:/path/to/socket
+	if path, ok := strings.CutPrefix(rest, "unix:"); ok {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("has socket path %q, expected an absolute path", path)
+		}
+		return nil
+	}
+
+	hostPort, _, _ := strings.Cut(rest, "/")
+
+	var host, port string
+	if strings.HasPrefix(hostPort, "[") {
+		end := strings.Index(hostPort, "]")
+		if end < 0 {
+			return fmt.Errorf("has an unterminated IPv6 address")
+		}
+
+		host = hostPort[1:end]
+		if net.ParseIP(host) == nil || !strings.Contains(host, ":") {
+			return fmt.Errorf("has host %q, expected an IPv6 address between the brackets", host)
+		}
+
+		rest := hostPort[end+1:]
+		if rest != "" {
+			var found bool
+			port, found = strings.CutPrefix(rest, ":")
+			if !found {
+				return fmt.Errorf("has unexpected %q after the host", rest)
+			}
+		}
+	} else {
+		host = hostPort
+		if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+			host, port = hostPort[:i], hostPort[i+1:]
+		}
+
+		switch {
+		case host == "+", host == "*":
+		case net.ParseIP(host) != nil && !strings.Contains(host, ":"):
+		case hostNamePattern.MatchString(host):
+		case strings.ContainsAny(host, "+*"):
+			return fmt.Errorf("has host %q, but the + and * wildcards must make up the whole host", host)
+		default:
+			return fmt.Errorf("has host %q, expected a wildcard (+ or *), an IP address or a host name", host)
+		}
+	}
+
+	if port != "" {
+		if _, err := parsePort("port", port); err != nil {
+			return fmt.Errorf("has port %q, expected a number between 1 and 65535", port)
+		}
+	}
+
+	return nil
+}
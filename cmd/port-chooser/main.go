@@ -9,18 +9,21 @@ import (
 )
 
 // main will invoke the port chooser, and write all provided environment variables to FD 3.
+// Diagnostics and errors are written to stderr, and errors fail the launch.
 // See https://github.com/buildpacks/rfcs/blob/main/text/0093-remove-shell-processes.md.
 func main() {
 	execdWriter := os.NewFile(3, "/dev/fd/3")
 
-	envVars, err := internal.ChoosePort()
+	envVars, err := internal.ChoosePort(os.Stderr)
 	if err != nil {
-		return
+		fmt.Fprintf(os.Stderr, "port-chooser: %s\n", err)
+		os.Exit(1)
 	}
 
 	for k, v := range envVars {
 		if _, err := fmt.Fprintf(execdWriter, "%s=%s\n", k, strconv.Quote(v)); err != nil {
-			return
+			fmt.Fprintf(os.Stderr, "port-chooser: failed to write environment variables: %s\n", err)
+			os.Exit(1)
 		}
 	}
 }
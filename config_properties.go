@@ -0,0 +1,105 @@
+package dotnetexecute
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// Well-known runtime configuration knobs that can be set in the
+// runtimeOptions.configProperties section of a runtimeconfig.json file. See
+// https://learn.microsoft.com/en-us/dotnet/core/runtime-config/.
+const (
+	ConfigPropertyGCServer               = "System.GC.Server"
+	ConfigPropertyGCConcurrent           = "System.GC.Concurrent"
+	ConfigPropertyInvariantGlobalization = "System.Globalization.Invariant"
+	ConfigPropertyTieredPGO              = "System.Runtime.TieredPGO"
+)
+
+// ConfigProperties holds the runtimeOptions.configProperties of a
+// runtimeconfig.json file. Values keep the JSON type they were declared with;
+// the accessors convert them the same way the .NET host does, so a property
+// set to "true" is read as a boolean.
+type ConfigProperties map[string]interface{}
+
+// Bool returns the boolean value of the named property and whether it is set
+// to a value that can be read as a boolean.
+func (p ConfigProperties) Bool(name string) (bool, bool) {
+	switch value := p[name].(type) {
+	case bool:
+		return value, true
+	case string:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, false
+		}
+		return b, true
+	default:
+		return false, false
+	}
+}
+
+// Int returns the integer value of the named property and whether it is set
+// to a value that can be read as an integer.
+func (p ConfigProperties) Int(name string) (int64, bool) {
+	switch value := p[name].(type) {
+	case json.Number:
+		i, err := value.Int64()
+		if err != nil {
+			return 0, false
+		}
+		return i, true
+	case string:
+		i, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return 0, false
+		}
+		return i, true
+	default:
+		return 0, false
+	}
+}
+
+// String returns the value of the named property formatted as a string and
+// whether the property is set.
+func (p ConfigProperties) String(name string) (string, bool) {
+	value, ok := p[name]
+	if !ok {
+		return "", false
+	}
+
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	default:
+		return fmt.Sprintf("%v", value), true
+	}
+}
+
+// Names returns the names of all of the properties in lexical order.
+func (p ConfigProperties) Names() []string {
+	var names []string
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func logConfigProperties(logger scribe.Emitter, properties ConfigProperties) {
+	if len(properties) == 0 {
+		return
+	}
+
+	logger.Debug.Subprocess("Runtime configuration properties:")
+	for _, name := range properties.Names() {
+		value, _ := properties.String(name)
+		logger.Debug.Action("%s: %s", name, value)
+	}
+}
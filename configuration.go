@@ -24,6 +24,58 @@ type Configuration struct {
 
 	// When BP_DOTNET_PROJECT_PATH is set to a relative path, the buildpack
 	// will look for project file(s) in that subdirectory to determine which
-	// project to build into the app container.
+	// project to build into the app container. Prebuilt apps are run from
+	// that subdirectory as well.
 	ProjectPath string `env:"BP_DOTNET_PROJECT_PATH"`
+
+	// When the app directory contains several *.runtimeconfig.json files, each
+	// app gets its own process type. BP_DOTNET_DEFAULT_PROCESS names the app
+	// whose process is the default one; it defaults to the first app in
+	// lexical order.
+	DefaultProcess string `env:"BP_DOTNET_DEFAULT_PROCESS"`
+
+	// BP_DOTNET_WORKING_DIRECTORY sets the working directory of the app
+	// processes, which ASP.NET Core uses as its content root. Relative paths
+	// are resolved against the working directory (/workspace). It defaults to
+	// the directory containing the app.
+	WorkingDirectory string `env:"BP_DOTNET_WORKING_DIRECTORY"`
+
+	// BP_DOTNET_LAUNCH_ARGS holds extra arguments that are appended to the
+	// command of the app processes, including the live-reload ones. The value
+	// is split into arguments using shell quoting rules, without any variable
+	// expansion, e.g. BP_DOTNET_LAUNCH_ARGS="--urls 'http://+:9000'".
+	LaunchArgs string `env:"BP_DOTNET_LAUNCH_ARGS"`
+
+	// Each EF Core migration bundle published next to the app gets a migrate
+	// process type. When BP_DOTNET_MIGRATE_CONNECTION_ENV names an environment
+	// variable, the bundle is run with --connection set to the value of that
+	// variable at launch time, e.g. BP_DOTNET_MIGRATE_CONNECTION_ENV=DB_CONNECTION.
+	MigrateConnectionEnv string `env:"BP_DOTNET_MIGRATE_CONNECTION_ENV"`
+
+	// BP_DOTNET_SBOM_MODE selects how the SBOM of the app is generated: "scan"
+	// scans every file of the app, "deps" only reads the NuGet packages and
+	// shared frameworks recorded in its deps.json and runtimeconfig.json files,
+	// and "both" combines the two.
+	SBOMMode string `env:"BP_DOTNET_SBOM_MODE,default=scan"`
+
+	// BP_DOTNET_SBOM_PATHS is a colon-separated list of the files and
+	// directories of the app, relative to the app directory, that the SBOM is
+	// generated from. The whole app directory is used when it is not set.
+	SBOMPaths string `env:"BP_DOTNET_SBOM_PATHS"`
+
+	// BP_DOTNET_SBOM_EXCLUDE is a colon-separated list of glob patterns of the
+	// files and directories of the app, relative to the app directory, that are
+	// left out of the SBOM, e.g. BP_DOTNET_SBOM_EXCLUDE="wwwroot:**/node_modules".
+	SBOMExclude string `env:"BP_DOTNET_SBOM_EXCLUDE"`
+
+	// BP_DOTNET_ADVISORY_DB is the path of an advisory database in OSV JSON
+	// format, either a file or a directory of *.json files, that the NuGet
+	// packages of the app are checked against. A database can also be given
+	// through a binding of type dotnet-advisory-db.
+	AdvisoryDB string `env:"BP_DOTNET_ADVISORY_DB"`
+
+	// When BP_DOTNET_FAIL_ON_SEVERITY is set to low, moderate, high or
+	// critical, the build fails if the advisory database has an advisory of
+	// that severity or higher for one of the NuGet packages of the app.
+	FailOnSeverity string `env:"BP_DOTNET_FAIL_ON_SEVERITY"`
 }
@@ -0,0 +1,117 @@
+package dotnetexecute
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gravityblast/go-jsmin"
+)
+
+// The library types found in the libraries section of a deps.json file.
+const (
+	DepsLibraryTypePackage     = "package"
+	DepsLibraryTypeProject     = "project"
+	DepsLibraryTypeReference   = "reference"
+	DepsLibraryTypeRuntimePack = "runtimepack"
+)
+
+// DepsFile holds the dependency information the .NET SDK records in the
+// <app>.deps.json file of a published app.
+type DepsFile struct {
+	Path string
+
+	// RuntimeTarget is the runtimeTarget.name of the deps.json file, such as
+	// .NETCoreApp,Version=v8.0/linux-x64, and RID the runtime identifier it
+	// ends with. RID is empty for portable apps.
+	RuntimeTarget string
+	RID           string
+
+	Libraries []DepsLibrary
+}
+
+// DepsLibrary is an entry of the libraries section of a deps.json file.
+type DepsLibrary struct {
+	Name     string
+	Version  string
+	Type     string
+	Path     string
+	SHA512   string
+	HashPath string
+}
+
+type DepsParser struct{}
+
+func NewDepsParser() DepsParser {
+	return DepsParser{}
+}
+
+// Parse parses the given deps.json file. Libraries are returned in lexical
+// order of their names and versions.
+func (p DepsParser) Parse(path string) (DepsFile, error) {
+	var data struct {
+		RuntimeTarget struct {
+			Name string `json:"name"`
+		} `json:"runtimeTarget"`
+		Libraries map[string]struct {
+			Type     string `json:"type"`
+			Path     string `json:"path"`
+			SHA512   string `json:"sha512"`
+			HashPath string `json:"hashPath"`
+		} `json:"libraries"`
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return DepsFile{}, fmt.Errorf("failed to open deps.json: %w", err)
+	}
+	defer file.Close()
+
+	buffer := bytes.NewBuffer(nil)
+	err = jsmin.Min(file, buffer)
+	if err != nil {
+		return DepsFile{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	err = json.NewDecoder(buffer).Decode(&data)
+	if err != nil {
+		return DepsFile{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	deps := DepsFile{
+		Path:          path,
+		RuntimeTarget: data.RuntimeTarget.Name,
+	}
+
+	if _, rid, found := strings.Cut(data.RuntimeTarget.Name, "/"); found {
+		deps.RID = rid
+	}
+
+	for key, library := range data.Libraries {
+		name, version, found := strings.Cut(key, "/")
+		if !found {
+			return DepsFile{}, fmt.Errorf("failed to parse %s: malformed library name %q", path, key)
+		}
+
+		deps.Libraries = append(deps.Libraries, DepsLibrary{
+			Name:     name,
+			Version:  version,
+			Type:     library.Type,
+			Path:     library.Path,
+			SHA512:   library.SHA512,
+			HashPath: library.HashPath,
+		})
+	}
+
+	sort.Slice(deps.Libraries, func(i, j int) bool {
+		if deps.Libraries[i].Name == deps.Libraries[j].Name {
+			return deps.Libraries[i].Version < deps.Libraries[j].Version
+		}
+		return deps.Libraries[i].Name < deps.Libraries[j].Name
+	})
+
+	return deps, nil
+}
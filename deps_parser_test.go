@@ -0,0 +1,147 @@
+package dotnetexecute_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
+	"github.com/sclevine/spec"
+)
+
+func testDepsParser(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+		parser     dotnetexecute.DepsParser
+	)
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		parser = dotnetexecute.NewDepsParser()
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("Parse", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`{
+  "runtimeTarget": {
+    "name": ".NETCoreApp,Version=v8.0",
+    "signature": ""
+  },
+  "libraries": {
+    "MyApp/1.0.0": {
+      "type": "project",
+      "serviceable": false,
+      "sha512": ""
+    },
+    "Newtonsoft.Json/13.0.3": {
+      "type": "package",
+      "serviceable": true,
+      "sha512": "sha512-HrC5BXdl00IP9zeV+0Z848QWPAoCr9P3bDEZguI+gkLcBKAOxix/tLEAAHC+UvDNPv4a2d18lOReHMOagPa+zQ==",
+      "path": "newtonsoft.json/13.0.3",
+      "hashPath": "newtonsoft.json.13.0.3.nupkg.sha512"
+    },
+    "Microsoft.Extensions.Logging/8.0.0": {
+      "type": "package",
+      "serviceable": true,
+      "sha512": "sha512-some-hash",
+      "path": "microsoft.extensions.logging/8.0.0",
+      "hashPath": "microsoft.extensions.logging.8.0.0.nupkg.sha512"
+    }
+  }
+}`), 0600)).To(Succeed())
+		})
+
+		it("returns the libraries in lexical order", func() {
+			deps, err := parser.Parse(filepath.Join(workingDir, "MyApp.deps.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deps).To(Equal(dotnetexecute.DepsFile{
+				Path:          filepath.Join(workingDir, "MyApp.deps.json"),
+				RuntimeTarget: ".NETCoreApp,Version=v8.0",
+				Libraries: []dotnetexecute.DepsLibrary{
+					{
+						Name:     "Microsoft.Extensions.Logging",
+						Version:  "8.0.0",
+						Type:     "package",
+						Path:     "microsoft.extensions.logging/8.0.0",
+						SHA512:   "sha512-some-hash",
+						HashPath: "microsoft.extensions.logging.8.0.0.nupkg.sha512",
+					},
+					{
+						Name:    "MyApp",
+						Version: "1.0.0",
+						Type:    "project",
+					},
+					{
+						Name:     "Newtonsoft.Json",
+						Version:  "13.0.3",
+						Type:     "package",
+						Path:     "newtonsoft.json/13.0.3",
+						SHA512:   "sha512-HrC5BXdl00IP9zeV+0Z848QWPAoCr9P3bDEZguI+gkLcBKAOxix/tLEAAHC+UvDNPv4a2d18lOReHMOagPa+zQ==",
+						HashPath: "newtonsoft.json.13.0.3.nupkg.sha512",
+					},
+				},
+			}))
+		})
+
+		context("when the app was published for a runtime identifier", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`{
+  "runtimeTarget": {
+    "name": ".NETCoreApp,Version=v8.0/linux-musl-x64",
+    "signature": ""
+  },
+  "libraries": {}
+}`), 0600)).To(Succeed())
+			})
+
+			it("returns the runtime identifier", func() {
+				deps, err := parser.Parse(filepath.Join(workingDir, "MyApp.deps.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deps.RuntimeTarget).To(Equal(".NETCoreApp,Version=v8.0/linux-musl-x64"))
+				Expect(deps.RID).To(Equal("linux-musl-x64"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns an error", func() {
+					_, err := parser.Parse(filepath.Join(workingDir, "Missing.deps.json"))
+					Expect(err).To(MatchError(ContainSubstring("failed to open deps.json")))
+					Expect(err).To(MatchError(os.ErrNotExist))
+				})
+			})
+
+			context("when the file is malformed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`%%%`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.Parse(filepath.Join(workingDir, "MyApp.deps.json"))
+					Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+				})
+			})
+
+			context("when a library name has no version", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`{"libraries": {"MyApp": {"type": "project"}}}`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.Parse(filepath.Join(workingDir, "MyApp.deps.json"))
+					Expect(err).To(MatchError(ContainSubstring(`malformed library name "MyApp"`)))
+				})
+			})
+		})
+	})
+}
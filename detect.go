@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/Netflix/go-env"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
@@ -20,21 +22,26 @@ type BuildPlanMetadata struct {
 
 //go:generate faux --interface ConfigParser --output fakes/config_parser.go
 type ConfigParser interface {
-	Parse(glob string) (RuntimeConfig, error)
+	ParseAll(glob string) ([]RuntimeConfig, error)
 }
 
 //go:generate faux --interface ProjectParser --output fakes/project_parser.go
 type ProjectParser interface {
 	FindProjectFile(root string) (string, error)
 	NodeIsRequired(path string) (bool, error)
+	InvariantGlobalizationEnabled(path string) (bool, error)
 }
 
 // Detect will return a packit.DetectFunc that will be invoked during the
 // detect phase of the buildpack lifecycle.
 //
 // Detection will contribute a Build Plan that requires different things
-// depending on the type of app being built. See Configuration for details
-// on how environment variable configuration influences detection.
+// depending on the AppKind of the app being built, as inferred by Inspector.
+// See Configuration for details on how environment variable configuration
+// influences detection. When the app directory contains several
+// *.runtimeconfig.json files, the framework requirements of all of them are
+// merged into a single requirement, and detection fails when no runtime
+// version satisfies all of them.
 //
 // # Source Code Apps
 //
@@ -45,18 +52,33 @@ type ProjectParser interface {
 // # Framework-dependent Deployments
 //
 // The buildpack will require the .NET Core ASP.NET Runtime at launch-time to
-// run the framework-dependent app. It will require ICU at launch time. It will
-// require Nodejs if the app relies on JavaScript components.
+// run the framework-dependent app, constrained to the framework versions the
+// .NET host would accept given the framework version and roll-forward policy
+// in the app's *.runtimeconfig.json. Apps that only reference
+// Microsoft.NETCore.App require the .NET Core Runtime instead, with an
+// alternative plan that accepts the ASP.NET Core Runtime. It will require ICU
+// at launch time. It will require Nodejs if the app relies on JavaScript
+// components.
 //
 // # Framework-dependent Executables
 //
 // The buildpack will require the .NET Core ASP.NET Runtime at launch-time to
-// run the framework-dependent app. It will require ICU at launch time. It will
-// require Nodejs at launch time if the app relies on JavaScript components.
+// run the framework-dependent app, constrained to the framework versions the
+// .NET host would accept given the framework version and roll-forward policy
+// in the app's *.runtimeconfig.json. Apps that only reference
+// Microsoft.NETCore.App require the .NET Core Runtime instead, with an
+// alternative plan that accepts the ASP.NET Core Runtime. It will require ICU
+// at launch time. It will require Nodejs at launch time if the app relies on
+// JavaScript components.
 //
 // Self-contained Executables
 // The buildpack will require ICU at launch time. It will require Nodejs at
 // launch time if the app relies on JavaScript components.
+//
+// For all app types, ICU is not required when every app enables globalization
+// invariant mode, either through the System.Globalization.Invariant
+// runtimeconfig.json property or, for apps that do not set it, the
+// InvariantGlobalization project property.
 func Detect(
 	config Configuration,
 	logger scribe.Emitter,
@@ -105,34 +127,95 @@ func Detect(
 
 		logger.Debug.Process("Looking for .NET project files in '%s'", root)
 
-		runtimeConfig, err := configParser.Parse(filepath.Join(root, "*.runtimeconfig.json"))
+		runtimeConfigs, err := configParser.ParseAll(filepath.Join(root, "*.runtimeconfig.json"))
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
 			return packit.DetectResult{}, err
 		}
-		// FDE + FDD cases
-		if runtimeConfig.RuntimeVersion != "" {
-			logger.Debug.Subprocess("Detected '%s'", filepath.Join(root, fmt.Sprintf("%s.runtimeconfig.json", runtimeConfig.AppName)))
-			logger.Debug.Break()
-
-			requirements = append(requirements, packit.BuildPlanRequirement{
-				Name: "dotnet-core-aspnet-runtime",
-				Metadata: BuildPlanMetadata{
-					Launch: true,
-				},
-			})
-		}
 
 		projectFile, err := projectParser.FindProjectFile(root)
 		if err != nil {
 			return packit.DetectResult{}, err
 		}
 
-		if runtimeConfig.Path == "" && projectFile == "" {
+		if len(runtimeConfigs) == 0 && projectFile == "" {
 			return packit.DetectResult{}, packit.Fail.WithMessage("no *.runtimeconfig.json or project file found")
 		}
 
+		// Apps that only reference Microsoft.NETCore.App do not need the ASP.NET
+		// Core shared framework
+		runtimeRequirement := "dotnet-core-runtime"
 		if projectFile != "" {
-			logger.Debug.Subprocess("Detected '%s'", projectFile)
+			runtimeRequirement = "dotnet-core-aspnet-runtime"
+		}
+
+		// FDE + FDD cases, merging the framework requirements of every app so
+		// that a single runtime satisfies all of them
+		var constraints []string
+		var versionSource string
+		var frameworks []appFramework
+		inspector := NewInspector()
+		for _, runtimeConfig := range runtimeConfigs {
+			kind, evidence := inspector.Inspect(runtimeConfig, "")
+			logAppKind(logger, runtimeConfig.Path, kind, evidence)
+			logConfigProperties(logger, runtimeConfig.ConfigProperties)
+			logger.Debug.Break()
+
+			// Self-contained apps bring their own runtime
+			if kind == AppKindSelfContainedExecutable {
+				continue
+			}
+
+			version := runtimeConfig.RuntimeVersion
+			if runtimeConfig.ASPNETVersion != "" {
+				version = runtimeConfig.ASPNETVersion
+				runtimeRequirement = "dotnet-core-aspnet-runtime"
+			}
+
+			constraint, err := runtimeVersionConstraint(version, runtimeConfig.RollForward)
+			if err != nil {
+				return packit.DetectResult{}, fmt.Errorf("failed to parse framework version from %s: %w", runtimeConfig.Path, err)
+			}
+
+			if versionSource == "" {
+				versionSource = filepath.Base(runtimeConfig.Path)
+			}
+
+			if constraint != "*" {
+				frameworks = append(frameworks, appFramework{
+					source:     filepath.Base(runtimeConfig.Path),
+					version:    semver.MustParse(version),
+					constraint: constraint,
+				})
+			}
+
+			if constraint != "*" && !slices.Contains(constraints, constraint) {
+				constraints = append(constraints, constraint)
+			}
+		}
+
+		err = checkFrameworkCompatibility(frameworks)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if versionSource != "" {
+			constraint := "*"
+			if len(constraints) > 0 {
+				constraint = strings.Join(constraints, ", ")
+			}
+
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: runtimeRequirement,
+				Metadata: BuildPlanMetadata{
+					Version:       constraint,
+					VersionSource: versionSource,
+					Launch:        true,
+				},
+			})
+		}
+
+		if kind, evidence := inspector.Inspect(RuntimeConfig{}, projectFile); kind == AppKindSource {
+			logAppKind(logger, projectFile, kind, evidence)
 			logger.Debug.Break()
 
 			requirements = append(requirements, packit.BuildPlanRequirement{
@@ -165,25 +248,148 @@ func Detect(
 			}
 		}
 
-		// ICU will always be append onto the build plan requirements
-		requirements = append(requirements, packit.BuildPlanRequirement{
-			Name: "icu",
-			Metadata: BuildPlanMetadata{
-				Launch: true,
-			},
-		})
+		projectInvariant := false
+		if projectFile != "" {
+			projectInvariant, err = projectParser.InvariantGlobalizationEnabled(projectFile)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
+		}
+
+		// Every app sharing the image has to run in invariant mode for ICU to be
+		// dropped. The runtimeconfig.json setting of an app takes precedence
+		// over the project file, which only applies to apps that do not set it.
+		invariantGlobalization := projectInvariant
+		if len(runtimeConfigs) > 0 {
+			invariantGlobalization = true
+			for _, runtimeConfig := range runtimeConfigs {
+				invariant, ok := runtimeConfig.ConfigProperties.Bool(ConfigPropertyInvariantGlobalization)
+				if !ok {
+					invariant = projectInvariant
+				}
+				invariantGlobalization = invariantGlobalization && invariant
+			}
+		}
+
+		// Apps running in globalization invariant mode never load ICU
+		if invariantGlobalization {
+			logger.Debug.Subprocess("Globalization invariant mode is enabled, ICU is not required")
+			logger.Debug.Break()
+		} else {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name: "icu",
+				Metadata: BuildPlanMetadata{
+					Launch: true,
+				},
+			})
+		}
+
+		plan := packit.BuildPlan{
+			Requires: requirements,
+		}
+
+		// The .NET Core Runtime buildpack is not part of every builder, so fall
+		// back to the ASP.NET Core Runtime, which also provides the .NET Core
+		// Runtime, when it cannot be resolved.
+		if runtimeRequirement == "dotnet-core-runtime" && versionSource != "" {
+			var alternative []packit.BuildPlanRequirement
+			for _, req := range requirements {
+				if req.Name == runtimeRequirement {
+					req.Name = "dotnet-core-aspnet-runtime"
+				}
+				alternative = append(alternative, req)
+			}
+
+			plan.Or = []packit.BuildPlan{
+				{Requires: alternative},
+			}
+		}
 
 		logger.Debug.Process("Returning build plan")
-		logger.Debug.Subprocess("Requirements:")
-		for _, req := range requirements {
-			logger.Debug.Action(req.Name)
+		logRequirements(logger, "Requirements:", plan.Requires)
+		for _, alternative := range plan.Or {
+			logRequirements(logger, "Or requirements:", alternative.Requires)
 		}
 		logger.Debug.Break()
 
 		return packit.DetectResult{
-			Plan: packit.BuildPlan{
-				Requires: requirements,
-			},
+			Plan: plan,
 		}, nil
 	}
 }
+
+func logRequirements(logger scribe.Emitter, title string, requirements []packit.BuildPlanRequirement) {
+	logger.Debug.Subprocess(title)
+	for _, req := range requirements {
+		if metadata, ok := req.Metadata.(BuildPlanMetadata); ok && metadata.Version != "" {
+			logger.Debug.Action("%s: %s", req.Name, metadata.Version)
+			continue
+		}
+		logger.Debug.Action(req.Name)
+	}
+}
+
+// runtimeVersionConstraint converts a framework version from a
+// runtimeconfig.json file into a constraint that matches the versions the
+// .NET host would accept at launch under the given roll-forward policy.
+func runtimeVersionConstraint(version, rollForward string) (string, error) {
+	if version == "*" {
+		return version, nil
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	switch rollForward {
+	case RollForwardDisable:
+		return v.String(), nil
+	case RollForwardLatestPatch:
+		return fmt.Sprintf("~%s", v.String()), nil
+	case RollForwardMajor, RollForwardLatestMajor:
+		return fmt.Sprintf(">= %s", v.String()), nil
+	default:
+		return fmt.Sprintf("^%s", v.String()), nil
+	}
+}
+
+// appFramework is the framework version an app requires, along with the
+// constraint derived from it.
+type appFramework struct {
+	source     string
+	version    *semver.Version
+	constraint string
+}
+
+// checkFrameworkCompatibility returns an error when no single runtime version
+// satisfies the constraints of all the given apps. Each constraint accepts a
+// range of versions starting at the version of the app, so they are
+// compatible when the highest of those versions satisfies all of them.
+func checkFrameworkCompatibility(frameworks []appFramework) error {
+	if len(frameworks) < 2 {
+		return nil
+	}
+
+	highest := frameworks[0]
+	for _, framework := range frameworks[1:] {
+		if framework.version.GreaterThan(highest.version) {
+			highest = framework
+		}
+	}
+
+	for _, framework := range frameworks {
+		constraint, err := semver.NewConstraint(framework.constraint)
+		if err != nil {
+			// not tested
+			return err
+		}
+
+		if !constraint.Check(highest.version) {
+			return fmt.Errorf("the apps require incompatible .NET runtime versions: %s requires %s, but %s requires %s: publish them for the same .NET version, or as self-contained apps",
+				framework.source, framework.constraint, highest.source, highest.constraint)
+		}
+	}
+
+	return nil
+}
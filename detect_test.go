@@ -3,6 +3,7 @@ package dotnetexecute_test
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -36,8 +37,10 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		Expect(err).NotTo(HaveOccurred())
 
 		runtimeConfigParser = &fakes.ConfigParser{}
-		runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-			Path: filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+		runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+			{
+				Path: filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+			},
 		}
 		projectParser = &fakes.ProjectParser{}
 
@@ -53,9 +56,11 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 
 	context("there is a *.runtimeconfig.json file present", func() {
 		it.Before(func() {
-			runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-				Path:       filepath.Join(workingDir, "some-app.runtimeconfig.json"),
-				Executable: true,
+			runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:       filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+					Executable: true,
+				},
 			}
 		})
 
@@ -75,20 +80,22 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 		})
 
 		context("when the runtimeconfig.json specifies a runtime framework", func() {
 			it.Before(func() {
-				runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
-					RuntimeVersion: "2.1.0",
-					Executable:     true,
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						RuntimeVersion: "2.1.0",
+						Executable:     true,
+					},
 				}
 			})
 
-			it("requires dotnet-core-aspnet-runtime", func() {
+			it("requires dotnet-core-runtime, or dotnet-core-aspnet-runtime as an alternative", func() {
 				result, err := detect(packit.DetectContext{
 					WorkingDir: workingDir,
 				})
@@ -96,9 +103,11 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				Expect(result.Plan).To(Equal(packit.BuildPlan{
 					Requires: []packit.BuildPlanRequirement{
 						{
-							Name: "dotnet-core-aspnet-runtime",
+							Name: "dotnet-core-runtime",
 							Metadata: dotnetexecute.BuildPlanMetadata{
-								Launch: true,
+								Version:       "^2.1.0",
+								VersionSource: "some-app.runtimeconfig.json",
+								Launch:        true,
 							},
 						},
 						{
@@ -108,23 +117,70 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 							},
 						},
 					},
+					Or: []packit.BuildPlan{
+						{
+							Requires: []packit.BuildPlanRequirement{
+								{
+									Name: "dotnet-core-aspnet-runtime",
+									Metadata: dotnetexecute.BuildPlanMetadata{
+										Version:       "^2.1.0",
+										VersionSource: "some-app.runtimeconfig.json",
+										Launch:        true,
+									},
+								},
+								{
+									Name: "icu",
+									Metadata: dotnetexecute.BuildPlanMetadata{
+										Launch: true,
+									},
+								},
+							},
+						},
+					},
 				}))
 
-				Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+				Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 				Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			})
 		})
 
+		context("when debug logging is enabled", func() {
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						AppName:        "some-app",
+						RuntimeVersion: "2.1.0",
+					},
+				}
+
+				detect = dotnetexecute.Detect(dotnetexecute.Configuration{}, scribe.NewEmitter(buffer).WithLevel("DEBUG"), runtimeConfigParser, projectParser)
+			})
+
+			it("logs the kind of the app and the evidence for it", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Detected framework-dependent-deployment app '%s'", filepath.Join(workingDir, "some-app.runtimeconfig.json"))))
+				Expect(buffer.String()).To(ContainSubstring("references Microsoft.NETCore.App framework version 2.1.0"))
+				Expect(buffer.String()).To(ContainSubstring("found no executable 'some-app'"))
+			})
+		})
+
 		context("when there is no executable", func() {
 			it.Before(func() {
-				runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
-					RuntimeVersion: "2.1.0",
-					Executable:     false,
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						RuntimeVersion: "2.1.0",
+						Executable:     false,
+					},
 				}
 			})
 
-			it("requires dotnet-core-aspnet-runtime", func() {
+			it("requires dotnet-core-runtime, or dotnet-core-aspnet-runtime as an alternative", func() {
 				result, err := detect(packit.DetectContext{
 					WorkingDir: workingDir,
 				})
@@ -132,9 +188,11 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				Expect(result.Plan).To(Equal(packit.BuildPlan{
 					Requires: []packit.BuildPlanRequirement{
 						{
-							Name: "dotnet-core-aspnet-runtime",
+							Name: "dotnet-core-runtime",
 							Metadata: dotnetexecute.BuildPlanMetadata{
-								Launch: true,
+								Version:       "^2.1.0",
+								VersionSource: "some-app.runtimeconfig.json",
+								Launch:        true,
 							},
 						},
 						{
@@ -144,24 +202,116 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 							},
 						},
 					},
+					Or: []packit.BuildPlan{
+						{
+							Requires: []packit.BuildPlanRequirement{
+								{
+									Name: "dotnet-core-aspnet-runtime",
+									Metadata: dotnetexecute.BuildPlanMetadata{
+										Version:       "^2.1.0",
+										VersionSource: "some-app.runtimeconfig.json",
+										Launch:        true,
+									},
+								},
+								{
+									Name: "icu",
+									Metadata: dotnetexecute.BuildPlanMetadata{
+										Launch: true,
+									},
+								},
+							},
+						},
+					},
 				}))
 
-				Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+				Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 				Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			})
 		})
 
+		context("when the runtimeconfig.json specifies a roll-forward policy", func() {
+			var rollForward string
+
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Stub = func(string) ([]dotnetexecute.RuntimeConfig, error) {
+					return []dotnetexecute.RuntimeConfig{
+						{
+							Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+							RuntimeVersion: "8.0.1",
+							RollForward:    rollForward,
+						},
+					}, nil
+				}
+			})
+
+			for policy, constraint := range map[string]string{
+				dotnetexecute.RollForwardDisable:     "8.0.1",
+				dotnetexecute.RollForwardLatestPatch: "~8.0.1",
+				dotnetexecute.RollForwardMinor:       "^8.0.1",
+				dotnetexecute.RollForwardLatestMinor: "^8.0.1",
+				dotnetexecute.RollForwardMajor:       ">= 8.0.1",
+				dotnetexecute.RollForwardLatestMajor: ">= 8.0.1",
+			} {
+				policy, constraint := policy, constraint
+
+				it(fmt.Sprintf("requires dotnet-core-runtime %s for %s", constraint, policy), func() {
+					rollForward = policy
+
+					result, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+						Name: "dotnet-core-runtime",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Version:       constraint,
+							VersionSource: "some-app.runtimeconfig.json",
+							Launch:        true,
+						},
+					}))
+				})
+			}
+		})
+
+		context("when the runtimeconfig.json specifies a framework without a version", func() {
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						RuntimeVersion: "*",
+					},
+				}
+			})
+
+			it("requires any version of dotnet-core-runtime", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+					Name: "dotnet-core-runtime",
+					Metadata: dotnetexecute.BuildPlanMetadata{
+						Version:       "*",
+						VersionSource: "some-app.runtimeconfig.json",
+						Launch:        true,
+					},
+				}))
+			})
+		})
+
 		context("when the runtimeconfig.json specifies an ASP.NET framework", func() {
 			it.Before(func() {
-				runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{
-					Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
-					RuntimeVersion: "2.1.0",
-					ASPNETVersion:  "2.1.0",
-					Executable:     true,
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						RuntimeVersion: "2.1.0",
+						ASPNETVersion:  "2.1.4",
+						Executable:     true,
+					},
 				}
 			})
 
-			it("requires dotnet-core-aspnet-runtime", func() {
+			it("requires the ASP.NET version of dotnet-core-aspnet-runtime", func() {
 				result, err := detect(packit.DetectContext{
 					WorkingDir: workingDir,
 				})
@@ -171,7 +321,9 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 						{
 							Name: "dotnet-core-aspnet-runtime",
 							Metadata: dotnetexecute.BuildPlanMetadata{
-								Launch: true,
+								Version:       "^2.1.4",
+								VersionSource: "some-app.runtimeconfig.json",
+								Launch:        true,
 							},
 						},
 						{
@@ -183,12 +335,102 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 					},
 				}))
 
-				Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+				Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 				Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			})
 		})
 	})
 
+	context("there are multiple *.runtimeconfig.json files present", func() {
+		it.Before(func() {
+			runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "some-api.runtimeconfig.json"),
+					RuntimeVersion: "8.0.2",
+					ASPNETVersion:  "8.0.2",
+				},
+				{
+					Path:           filepath.Join(workingDir, "some-worker.runtimeconfig.json"),
+					RuntimeVersion: "8.0.0",
+					ConfigProperties: dotnetexecute.ConfigProperties{
+						dotnetexecute.ConfigPropertyInvariantGlobalization: true,
+					},
+				},
+				{
+					Path:           filepath.Join(workingDir, "some-tool.runtimeconfig.json"),
+					RuntimeVersion: "8.0.2",
+					RollForward:    dotnetexecute.RollForwardMinor,
+				},
+			}
+		})
+
+		it("merges their framework requirements", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-core-aspnet-runtime",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Version:       "^8.0.2, ^8.0.0",
+							VersionSource: "some-api.runtimeconfig.json",
+							Launch:        true,
+						},
+					},
+					{
+						Name: "icu",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+				},
+			}))
+		})
+	})
+
+	context("there are multiple *.runtimeconfig.json files requiring different majors", func() {
+		it.Before(func() {
+			runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "some-api.runtimeconfig.json"),
+					RuntimeVersion: "8.0.2",
+					ASPNETVersion:  "8.0.2",
+				},
+				{
+					Path:           filepath.Join(workingDir, "some-worker.runtimeconfig.json"),
+					RuntimeVersion: "6.0.0",
+				},
+			}
+		})
+
+		it("returns an error naming the conflicting files", func() {
+			_, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).To(MatchError("the apps require incompatible .NET runtime versions: some-worker.runtimeconfig.json requires ^6.0.0, but some-api.runtimeconfig.json requires ^8.0.2: publish them for the same .NET version, or as self-contained apps"))
+		})
+
+		context("when the older app rolls forward to the latest major", func() {
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice[1].RollForward = dotnetexecute.RollForwardLatestMajor
+			})
+
+			it("merges their framework requirements", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires[0].Metadata).To(Equal(dotnetexecute.BuildPlanMetadata{
+					Version:       "^8.0.2, >= 6.0.0",
+					VersionSource: "some-api.runtimeconfig.json",
+					Launch:        true,
+				}))
+			})
+		})
+	})
+
 	context("there is a proj file present (and no .runtimeconfig.json)", func() {
 		it.Before(func() {
 			projectParser.FindProjectFileCall.Returns.String = "/path/to/some-file.csproj"
@@ -222,7 +464,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 
 			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
@@ -262,7 +504,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 
 			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
@@ -302,7 +544,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 
 			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
@@ -350,13 +592,132 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				},
 			}))
 
-			Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
+			Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "*.runtimeconfig.json")))
 
 			Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(workingDir))
 			Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
 		})
 	})
 
+	context("when the runtimeconfig.json enables globalization invariant mode", func() {
+		it.Before(func() {
+			runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+					RuntimeVersion: "8.0.0",
+					ASPNETVersion:  "8.0.0",
+					ConfigProperties: dotnetexecute.ConfigProperties{
+						dotnetexecute.ConfigPropertyInvariantGlobalization: true,
+					},
+				},
+			}
+		})
+
+		it("does not require icu", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-core-aspnet-runtime",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Version:       "^8.0.0",
+							VersionSource: "some-app.runtimeconfig.json",
+							Launch:        true,
+						},
+					},
+				},
+			}))
+		})
+	})
+
+	context("when the proj file enables InvariantGlobalization", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = "/path/to/some-file.csproj"
+			projectParser.InvariantGlobalizationEnabledCall.Returns.Bool = true
+		})
+
+		it("does not require icu", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan).To(Equal(packit.BuildPlan{
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "dotnet-application",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+					{
+						Name: "dotnet-core-aspnet-runtime",
+						Metadata: dotnetexecute.BuildPlanMetadata{
+							Launch: true,
+						},
+					},
+				},
+			}))
+
+			Expect(projectParser.InvariantGlobalizationEnabledCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
+		})
+	})
+
+	context("when the proj file enables InvariantGlobalization but a runtimeconfig.json disables it", func() {
+		it.Before(func() {
+			projectParser.FindProjectFileCall.Returns.String = "/path/to/some-file.csproj"
+			projectParser.InvariantGlobalizationEnabledCall.Returns.Bool = true
+
+			runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+				{
+					Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+					RuntimeVersion: "8.0.0",
+				},
+				{
+					Path:           filepath.Join(workingDir, "some-worker.runtimeconfig.json"),
+					RuntimeVersion: "8.0.0",
+					ConfigProperties: dotnetexecute.ConfigProperties{
+						dotnetexecute.ConfigPropertyInvariantGlobalization: false,
+					},
+				},
+			}
+		})
+
+		it("requires icu", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+				Name: "icu",
+				Metadata: dotnetexecute.BuildPlanMetadata{
+					Launch: true,
+				},
+			}))
+		})
+
+		context("when the runtimeconfig.json files do not set it", func() {
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice[1].ConfigProperties = nil
+			})
+
+			it("follows the proj file and does not require icu", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				var names []string
+				for _, requirement := range result.Plan.Requires {
+					names = append(names, requirement.Name)
+				}
+				Expect(names).NotTo(ContainElement("icu"))
+			})
+		})
+	})
+
 	context("when BP_DOTNET_PROJECT_PATH sets a custom project-path", func() {
 		it.Before(func() {
 			detect = dotnetexecute.Detect(dotnetexecute.Configuration{
@@ -375,7 +736,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(runtimeConfigParser.ParseCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "src/proj1", "*.runtimeconfig.json")))
+				Expect(runtimeConfigParser.ParseAllCall.Receives.Glob).To(Equal(filepath.Join(workingDir, "src/proj1", "*.runtimeconfig.json")))
 
 				Expect(projectParser.FindProjectFileCall.Receives.Root).To(Equal(filepath.Join(workingDir, "src/proj1")))
 				Expect(projectParser.NodeIsRequiredCall.Receives.Path).To(Equal("/path/to/some-file.csproj"))
@@ -430,7 +791,7 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 	context("failure cases", func() {
 		context("when the runtime config parsing fails", func() {
 			it.Before(func() {
-				runtimeConfigParser.ParseCall.Returns.Error = errors.New("failed to parse runtime config")
+				runtimeConfigParser.ParseAllCall.Returns.Error = errors.New("failed to parse runtime config")
 			})
 
 			it("fails", func() {
@@ -442,9 +803,28 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the runtimeconfig.json framework version cannot be parsed", func() {
+			it.Before(func() {
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = []dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						RuntimeVersion: "not-a-version",
+					},
+				}
+			})
+
+			it("fails", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse framework version from")))
+				Expect(err).To(MatchError(ContainSubstring("some-app.runtimeconfig.json")))
+			})
+		})
+
 		context("there is no *.runtimeconfig.json or project file present", func() {
 			it.Before(func() {
-				runtimeConfigParser.ParseCall.Returns.RuntimeConfig = dotnetexecute.RuntimeConfig{}
+				runtimeConfigParser.ParseAllCall.Returns.RuntimeConfigSlice = nil
 			})
 
 			it("detection fails", func() {
@@ -469,6 +849,20 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("parsing the InvariantGlobalization property from the project file fails", func() {
+			it.Before(func() {
+				projectParser.InvariantGlobalizationEnabledCall.Returns.Error = errors.New("some-error")
+				projectParser.FindProjectFileCall.Returns.String = "/path/to/some-file.csproj"
+			})
+
+			it("fails", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError("some-error"))
+			})
+		})
+
 		context("parsing the node requirement from the project file fails", func() {
 			it.Before(func() {
 				projectParser.NodeIsRequiredCall.Returns.Error = errors.New("some-error")
@@ -7,27 +7,27 @@ import (
 )
 
 type ConfigParser struct {
-	ParseCall struct {
+	ParseAllCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
 			Glob string
 		}
 		Returns struct {
-			RuntimeConfig dotnetexecute.RuntimeConfig
-			Error         error
+			RuntimeConfigSlice []dotnetexecute.RuntimeConfig
+			Error              error
 		}
-		Stub func(string) (dotnetexecute.RuntimeConfig, error)
+		Stub func(string) ([]dotnetexecute.RuntimeConfig, error)
 	}
 }
 
-func (f *ConfigParser) Parse(param1 string) (dotnetexecute.RuntimeConfig, error) {
-	f.ParseCall.mutex.Lock()
-	defer f.ParseCall.mutex.Unlock()
-	f.ParseCall.CallCount++
-	f.ParseCall.Receives.Glob = param1
-	if f.ParseCall.Stub != nil {
-		return f.ParseCall.Stub(param1)
+func (f *ConfigParser) ParseAll(param1 string) ([]dotnetexecute.RuntimeConfig, error) {
+	f.ParseAllCall.mutex.Lock()
+	defer f.ParseAllCall.mutex.Unlock()
+	f.ParseAllCall.CallCount++
+	f.ParseAllCall.Receives.Glob = param1
+	if f.ParseAllCall.Stub != nil {
+		return f.ParseAllCall.Stub(param1)
 	}
-	return f.ParseCall.Returns.RuntimeConfig, f.ParseCall.Returns.Error
+	return f.ParseAllCall.Returns.RuntimeConfigSlice, f.ParseAllCall.Returns.Error
 }
@@ -15,6 +15,18 @@ type ProjectParser struct {
 		}
 		Stub func(string) (string, error)
 	}
+	InvariantGlobalizationEnabledCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			Bool  bool
+			Error error
+		}
+		Stub func(string) (bool, error)
+	}
 	NodeIsRequiredCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -39,6 +51,16 @@ func (f *ProjectParser) FindProjectFile(param1 string) (string, error) {
 	}
 	return f.FindProjectFileCall.Returns.String, f.FindProjectFileCall.Returns.Error
 }
+func (f *ProjectParser) InvariantGlobalizationEnabled(param1 string) (bool, error) {
+	f.InvariantGlobalizationEnabledCall.mutex.Lock()
+	defer f.InvariantGlobalizationEnabledCall.mutex.Unlock()
+	f.InvariantGlobalizationEnabledCall.CallCount++
+	f.InvariantGlobalizationEnabledCall.Receives.Path = param1
+	if f.InvariantGlobalizationEnabledCall.Stub != nil {
+		return f.InvariantGlobalizationEnabledCall.Stub(param1)
+	}
+	return f.InvariantGlobalizationEnabledCall.Returns.Bool, f.InvariantGlobalizationEnabledCall.Returns.Error
+}
 func (f *ProjectParser) NodeIsRequired(param1 string) (bool, error) {
 	f.NodeIsRequiredCall.mutex.Lock()
 	defer f.NodeIsRequiredCall.mutex.Unlock()
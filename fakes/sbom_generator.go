@@ -3,6 +3,7 @@ package fakes
 import (
 	"sync"
 
+	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
 )
 
@@ -11,23 +12,25 @@ type SBOMGenerator struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Path string
+			Path  string
+			Scope dotnetexecute.SBOMScope
 		}
 		Returns struct {
 			SBOM  sbom.SBOM
 			Error error
 		}
-		Stub func(string) (sbom.SBOM, error)
+		Stub func(string, dotnetexecute.SBOMScope) (sbom.SBOM, error)
 	}
 }
 
-func (f *SBOMGenerator) Generate(param1 string) (sbom.SBOM, error) {
+func (f *SBOMGenerator) Generate(param1 string, param2 dotnetexecute.SBOMScope) (sbom.SBOM, error) {
 	f.GenerateCall.mutex.Lock()
 	defer f.GenerateCall.mutex.Unlock()
 	f.GenerateCall.CallCount++
 	f.GenerateCall.Receives.Path = param1
+	f.GenerateCall.Receives.Scope = param2
 	if f.GenerateCall.Stub != nil {
-		return f.GenerateCall.Stub(param1)
+		return f.GenerateCall.Stub(param1, param2)
 	}
 	return f.GenerateCall.Returns.SBOM, f.GenerateCall.Returns.Error
 }
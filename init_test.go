@@ -10,8 +10,11 @@ import (
 func TestUnitDotnetExecute(t *testing.T) {
 	suite := spec.New("dotnet-execute", spec.Report(report.Terminal{}))
 	suite("Build", testBuild)
+	suite("Inspector", testInspector)
 	suite("Detect", testDetect)
 	suite("RuntimeConfigParser", testRuntimeConfigParser)
 	suite("ProjectFileParser", testProjectFileParser)
+	suite("DepsParser", testDepsParser)
+	suite("SBOMGenerator", testSBOMGenerator)
 	suite.Run(t)
 }
@@ -75,7 +75,7 @@ func testFrameworkDependentDeployment(t *testing.T, context spec.G, it spec.S) {
 			Eventually(func() string {
 				logs, _ := docker.Container.Logs.Execute(container.ID)
 				return logs.String()
-			}).Should(ContainSubstring(`Setting ASPNETCORE_URLS=http://0.0.0.0:8080`))
+			}).Should(ContainSubstring(`Setting ASPNETCORE_HTTP_PORTS=8080`))
 
 			Eventually(container).Should(Serve(ContainSubstring("Welcome")).OnPort(8080))
 		})
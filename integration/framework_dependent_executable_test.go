@@ -136,7 +136,7 @@ func testFrameworkDependentExecutable(t *testing.T, context spec.G, it spec.S) {
 				Eventually(func() string {
 					logs, _ := docker.Container.Logs.Execute(container.ID)
 					return logs.String()
-				}).Should(ContainSubstring(`Now listening on: http://0.0.0.0:8080`))
+				}).Should(ContainSubstring(`Now listening on: http://[::]:8080`))
 
 				noReloadContainer, err = docker.Container.Run.WithEntrypoint("framework_dependent_8").Execute(image.ID)
 				Expect(err).NotTo(HaveOccurred())
@@ -144,7 +144,7 @@ func testFrameworkDependentExecutable(t *testing.T, context spec.G, it spec.S) {
 				Eventually(func() string {
 					logs, _ := docker.Container.Logs.Execute(noReloadContainer.ID)
 					return logs.String()
-				}).Should(ContainSubstring(`Now listening on: http://0.0.0.0:8080`))
+				}).Should(ContainSubstring(`Now listening on: http://[::]:8080`))
 			})
 		})
 	})
@@ -0,0 +1,134 @@
+package dotnetexecute
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// migrationBundleSignature is the name of the entry assembly that the EF Core
+// tooling embeds in every migration bundle it produces with `dotnet ef
+// migrations bundle`, whatever the name of the bundle itself.
+var migrationBundleSignature = []byte("efbundle.dll")
+
+var environmentVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// findMigrationBundles returns the paths of the EF Core migration bundles in
+// the given directory, in lexical order. A migration bundle is a Linux ELF
+// executable whose name contains "efbundle", or a single-file bundle that
+// embeds the efbundle entry assembly, whatever its mode. See restoreExecBit.
+// The executables named in exclude, typically the apps themselves, are not
+// considered.
+func findMigrationBundles(root string, exclude []string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find migration bundles: %w", err)
+	}
+
+	var bundles []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || slices.Contains(exclude, entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(root, entry.Name())
+		info, ok, err := inspectELF(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find migration bundles: %w", err)
+		}
+		if !ok || !info.Executable {
+			continue
+		}
+
+		if strings.Contains(strings.ToLower(entry.Name()), "efbundle") {
+			bundles = append(bundles, path)
+			continue
+		}
+
+		// Only the files appended to the host by single-file publishing are
+		// searched, which leaves out plain executables such as createdump
+		isBundle, err := fileContains(path, info.ImageSize, migrationBundleSignature)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find migration bundles: %w", err)
+		}
+
+		if isBundle {
+			bundles = append(bundles, path)
+		}
+	}
+
+	return bundles, nil
+}
+
+// migrationCommand returns the command and arguments that run the given
+// migration bundle. When connectionEnv is set, the bundle is passed the value
+// of that environment variable at launch time as its connection string.
+func migrationCommand(bundle, connectionEnv string) (string, []string, error) {
+	if connectionEnv == "" {
+		return bundle, nil, nil
+	}
+
+	if !environmentVariableName.MatchString(connectionEnv) {
+		return "", nil, fmt.Errorf("invalid BP_DOTNET_MIGRATE_CONNECTION_ENV: %q is not an environment variable name", connectionEnv)
+	}
+
+	return "bash", []string{"-c", fmt.Sprintf(`exec %s --connection "${%s}"`, shellQuote(bundle), connectionEnv)}, nil
+}
+
+// migrationProcessType returns the process type of the given migration
+// bundle: "migrate" when it is the only one, otherwise "migrate-<name>".
+func migrationProcessType(bundle string, count int) string {
+	if count == 1 {
+		return "migrate"
+	}
+
+	return fmt.Sprintf("migrate-%s", filepath.Base(bundle))
+}
+
+// fileContains reports whether the file at the given path contains needle
+// past the given offset.
+func fileContains(path string, offset int64, needle []byte) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	if info.Size() <= offset {
+		return false, nil
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, info.Size()-offset))
+	buffer := make([]byte, 64*1024)
+	overlap := len(needle) - 1
+	tail := 0
+	for {
+		n, err := io.ReadFull(reader, buffer[tail:])
+		if bytes.Contains(buffer[:tail+n], needle) {
+			return true, nil
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		// Keep the end of the chunk around so that matches spanning two
+		// chunks are found
+		tail = copy(buffer, buffer[tail+n-overlap:tail+n])
+	}
+}
@@ -0,0 +1,69 @@
+package dotnetexecute
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// AppCommandPlaceholder is replaced in the commands of the extra process
+// types declared in a dotnet-execute.toml file with the command that starts
+// the default app.
+const AppCommandPlaceholder = "{{app}}"
+
+// extraProcess is an additional process type declared by the app in the
+// [[processes]] section of a dotnet-execute.toml file.
+type extraProcess struct {
+	Type    string `toml:"type"`
+	Command string `toml:"command"`
+}
+
+// parseExtraProcesses returns the process types declared in the
+// dotnet-execute.toml file of the given directory, which is not required.
+//
+// A Procfile is deliberately not read: the Procfile buildpack, which runs
+// after this one in the .NET Core composite, registers its process types as
+// written and would override any placeholder replaced here.
+func parseExtraProcesses(root string) ([]extraProcess, error) {
+	return parseProcessesTOML(filepath.Join(root, "dotnet-execute.toml"))
+}
+
+// procfileUsesPlaceholder reports whether the Procfile of the given directory
+// references AppCommandPlaceholder, which is left as is in its commands.
+func procfileUsesPlaceholder(root string) (bool, error) {
+	content, err := os.ReadFile(filepath.Join(root, "Procfile"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read Procfile: %w", err)
+	}
+
+	return strings.Contains(string(content), AppCommandPlaceholder), nil
+}
+
+func parseProcessesTOML(path string) ([]extraProcess, error) {
+	var data struct {
+		Processes []extraProcess `toml:"processes"`
+	}
+
+	_, err := toml.DecodeFile(path, &data)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to parse dotnet-execute.toml: %w", err)
+	}
+
+	for i, process := range data.Processes {
+		if process.Type == "" || process.Command == "" {
+			return nil, fmt.Errorf("failed to parse dotnet-execute.toml: processes[%d] must set both type and command", i)
+		}
+	}
+
+	return data.Processes, nil
+}
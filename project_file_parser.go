@@ -53,6 +53,35 @@ func (p ProjectFileParser) NodeIsRequired(path string) (bool, error) {
 	return needsNode || needsNPM, nil
 }
 
+// InvariantGlobalizationEnabled reports whether the project file sets the
+// InvariantGlobalization property to true.
+func (p ProjectFileParser) InvariantGlobalizationEnabled(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var project struct {
+		PropertyGroups []struct {
+			InvariantGlobalization string `xml:"InvariantGlobalization"`
+		} `xml:"PropertyGroup"`
+	}
+
+	err = xml.NewDecoder(file).Decode(&project)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	for _, group := range project.PropertyGroups {
+		if strings.EqualFold(strings.TrimSpace(group.InvariantGlobalization), "true") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (p ProjectFileParser) NPMIsRequired(path string) (bool, error) {
 	return findInFile("npm ", path)
 }
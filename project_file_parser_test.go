@@ -251,4 +251,86 @@ func testProjectFileParser(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("InvariantGlobalizationEnabled", func() {
+		var path string
+
+		it.Before(func() {
+			file, err := os.CreateTemp("", "app.csproj")
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			path = file.Name()
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(path)).To(Succeed())
+		})
+
+		context("when the project enables InvariantGlobalization", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`
+					<Project Sdk="Microsoft.NET.Sdk">
+						<PropertyGroup>
+							<TargetFramework>net8.0</TargetFramework>
+						</PropertyGroup>
+						<PropertyGroup>
+							<InvariantGlobalization>true</InvariantGlobalization>
+						</PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+			})
+
+			it("returns true", func() {
+				invariant, err := parser.InvariantGlobalizationEnabled(path)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(invariant).To(BeTrue())
+			})
+		})
+
+		context("when the project does NOT enable InvariantGlobalization", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`
+					<Project Sdk="Microsoft.NET.Sdk">
+						<PropertyGroup>
+							<TargetFramework>net8.0</TargetFramework>
+							<InvariantGlobalization>false</InvariantGlobalization>
+						</PropertyGroup>
+					</Project>
+				`), 0600)).To(Succeed())
+			})
+
+			it("returns false", func() {
+				invariant, err := parser.InvariantGlobalizationEnabled(path)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(invariant).To(BeFalse())
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file can not be opened", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(path)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.InvariantGlobalizationEnabled(path)
+					Expect(err.Error()).To(ContainSubstring("failed to open"))
+				})
+			})
+
+			context("when the file can not be decoded", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(path, []byte("%%%"), 0644)).To(Succeed())
+				})
+
+				it("errors", func() {
+					_, err := parser.InvariantGlobalizationEnabled(path)
+					Expect(err.Error()).To(ContainSubstring("failed to decode"))
+				})
+			})
+		})
+	})
 }
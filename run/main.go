@@ -9,16 +9,9 @@ import (
 	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
-	"github.com/paketo-buildpacks/packit/v2/sbom"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
-type Generator struct{}
-
-func (f Generator) Generate(path string) (sbom.SBOM, error) {
-	return sbom.Generate(path)
-}
-
 func main() {
 	var config dotnetexecute.Configuration
 	_, err := env.UnmarshalFromEnviron(&config)
@@ -40,7 +33,7 @@ func main() {
 		dotnetexecute.Build(
 			config,
 			configParser,
-			Generator{},
+			dotnetexecute.NewDotnetSBOMGenerator(config.SBOMMode),
 			logger,
 			chronos.DefaultClock,
 		),
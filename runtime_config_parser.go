@@ -3,7 +3,6 @@ package dotnetexecute
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,12 +11,41 @@ import (
 	"github.com/gravityblast/go-jsmin"
 )
 
+// The roll-forward policies understood by the .NET host when selecting a
+// shared framework version. See
+// https://learn.microsoft.com/en-us/dotnet/core/versions/selection#control-roll-forward-behavior.
+const (
+	RollForwardLatestPatch = "LatestPatch"
+	RollForwardMinor       = "Minor"
+	RollForwardLatestMinor = "LatestMinor"
+	RollForwardMajor       = "Major"
+	RollForwardLatestMajor = "LatestMajor"
+	RollForwardDisable     = "Disable"
+)
+
 type RuntimeConfig struct {
 	Path           string
 	RuntimeVersion string
 	ASPNETVersion  string
 	AppName        string
-	Executable     bool
+
+	// Executable is set when the app has a Linux apphost, an ELF executable
+	// named after the app, next to its runtimeconfig.json file, whatever its
+	// mode. See restoreExecBit.
+	Executable bool
+
+	// Arch is the architecture of the apphost, using GOARCH names such as
+	// amd64 or arm64. It is empty when the app has no apphost.
+	Arch string
+
+	// ConfigProperties are the runtimeOptions.configProperties of the
+	// runtimeconfig.json file.
+	ConfigProperties ConfigProperties
+
+	// RollForward is the roll-forward policy set in the runtimeconfig.json
+	// file or through the DOTNET_ROLL_FORWARD environment variable. It is
+	// empty when neither is set, in which case the host defaults to Minor.
+	RollForward string
 }
 
 type framework struct {
@@ -31,28 +59,51 @@ func NewRuntimeConfigParser() RuntimeConfigParser {
 	return RuntimeConfigParser{}
 }
 
-func (p RuntimeConfigParser) Parse(glob string) (RuntimeConfig, error) {
-	files, err := filepath.Glob(glob)
+// ParseAll parses every *.runtimeconfig.json file matching the given glob, in
+// lexical order of their paths.
+func (p RuntimeConfigParser) ParseAll(glob string) ([]RuntimeConfig, error) {
+	files, err := findRuntimeConfigs(glob)
 	if err != nil {
-		return RuntimeConfig{}, fmt.Errorf("failed to find *.runtimeconfig.json: %w: %q", err, glob)
+		return nil, err
+	}
+
+	var configs []RuntimeConfig
+	for _, file := range files {
+		config, err := parseRuntimeConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
 	}
 
-	if len(files) > 1 {
-		return RuntimeConfig{}, fmt.Errorf("multiple *.runtimeconfig.json files present: %v", files)
+	return configs, nil
+}
+
+func findRuntimeConfigs(glob string) ([]string, error) {
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find *.runtimeconfig.json: %w: %q", err, glob)
 	}
 
 	if len(files) == 0 {
-		return RuntimeConfig{}, fmt.Errorf("no *.runtimeconfig.json found: %w", os.ErrNotExist)
+		return nil, fmt.Errorf("no *.runtimeconfig.json found: %w", os.ErrNotExist)
 	}
 
+	return files, nil
+}
+
+func parseRuntimeConfig(path string) (RuntimeConfig, error) {
 	config := RuntimeConfig{
-		Path: files[0],
+		Path: path,
 	}
 
 	var data struct {
 		RuntimeOptions struct {
-			Framework  framework   `json:"framework"`
-			Frameworks []framework `json:"frameworks"`
+			Framework   framework   `json:"framework"`
+			Frameworks  []framework `json:"frameworks"`
+			RollForward string      `json:"rollForward"`
+
+			ConfigProperties ConfigProperties `json:"configProperties"`
 		} `json:"runtimeOptions"`
 	}
 
@@ -68,7 +119,10 @@ func (p RuntimeConfigParser) Parse(glob string) (RuntimeConfig, error) {
 		return RuntimeConfig{}, err
 	}
 
-	err = json.NewDecoder(buffer).Decode(&data)
+	decoder := json.NewDecoder(buffer)
+	decoder.UseNumber()
+
+	err = decoder.Decode(&data)
 	if err != nil {
 		return RuntimeConfig{}, err
 	}
@@ -101,15 +155,27 @@ func (p RuntimeConfigParser) Parse(glob string) (RuntimeConfig, error) {
 		}
 	}
 
-	config.AppName = strings.TrimSuffix(filepath.Base(file.Name()), ".runtimeconfig.json")
+	config.ConfigProperties = data.RuntimeOptions.ConfigProperties
 
-	info, err := os.Stat(strings.TrimSuffix(file.Name(), ".runtimeconfig.json"))
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return RuntimeConfig{}, err
+	// The runtimeconfig.json setting takes precedence over the environment
+	// variable, matching the behaviour of the .NET host.
+	rollForward := data.RuntimeOptions.RollForward
+	if rollForward == "" {
+		rollForward = os.Getenv("DOTNET_ROLL_FORWARD")
 	}
 
-	if info != nil && info.Mode()&0111 != 0 {
-		config.Executable = true
+	if rollForward != "" {
+		config.RollForward, err = rollForwardPolicy(rollForward)
+		if err != nil {
+			return RuntimeConfig{}, err
+		}
+	}
+
+	config.AppName = strings.TrimSuffix(filepath.Base(file.Name()), ".runtimeconfig.json")
+
+	config.Arch, config.Executable, err = inspectAppHost(strings.TrimSuffix(file.Name(), ".runtimeconfig.json"))
+	if err != nil {
+		return RuntimeConfig{}, err
 	}
 
 	return config, nil
@@ -121,3 +187,20 @@ func versionOrWildcard(version string) string {
 	}
 	return version
 }
+
+func rollForwardPolicy(value string) (string, error) {
+	for _, policy := range []string{
+		RollForwardLatestPatch,
+		RollForwardMinor,
+		RollForwardLatestMinor,
+		RollForwardMajor,
+		RollForwardLatestMajor,
+		RollForwardDisable,
+	} {
+		if strings.EqualFold(value, policy) {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported rollForward value: %q", value)
+}
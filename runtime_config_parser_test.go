@@ -1,6 +1,9 @@
 package dotnetexecute_test
 
 import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
 	"errors"
 	"os"
 	"path/filepath"
@@ -35,16 +38,49 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 		Expect(os.RemoveAll(workingDir)).To(Succeed())
 	})
 
-	context("Parse", func() {
+	context("ParseAll", func() {
 		it("parses the runtime config", func() {
-			config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+			configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 			Expect(err).NotTo(HaveOccurred())
+			Expect(configs).To(HaveLen(1))
+
+			config := configs[0]
 			Expect(config).To(Equal(dotnetexecute.RuntimeConfig{
 				Path:    filepath.Join(workingDir, "some-app.runtimeconfig.json"),
 				AppName: "some-app",
 			}))
 		})
 
+		context("when there are multiple runtimeconfig.json files", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "other-app.runtimeconfig.json"), []byte(`{
+					"runtimeOptions": {
+						"framework": {
+							"name": "Microsoft.AspNetCore.App",
+							"version": "8.0.0"
+						}
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("parses all of them in lexical order", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(Equal([]dotnetexecute.RuntimeConfig{
+					{
+						Path:           filepath.Join(workingDir, "other-app.runtimeconfig.json"),
+						AppName:        "other-app",
+						RuntimeVersion: "8.0.0",
+						ASPNETVersion:  "8.0.0",
+					},
+					{
+						Path:    filepath.Join(workingDir, "some-app.runtimeconfig.json"),
+						AppName: "some-app",
+					},
+				}))
+			})
+		})
+
 		context("when the runtime config includes comments", func() {
 			it.Before(func() {
 				Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`{
@@ -62,31 +98,150 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("parses the runtime config", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config).To(Equal(dotnetexecute.RuntimeConfig{
 					Path:    filepath.Join(workingDir, "some-app.runtimeconfig.json"),
 					AppName: "some-app",
+					ConfigProperties: dotnetexecute.ConfigProperties{
+						"System.GC.Server": true,
+					},
+				}))
+			})
+		})
+
+		context("when the runtime config includes configProperties", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`{
+					"runtimeOptions": {
+						"configProperties": {
+							"System.GC.Server": true,
+							"System.GC.Concurrent": "false",
+							"System.GC.HeapHardLimit": 209715200,
+							"System.Runtime.TieredPGO": "not-a-bool",
+							"Some.Custom.Switch": "some-value"
+						}
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("exposes them as typed values", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+
+				properties := config.ConfigProperties
+				Expect(properties.Names()).To(Equal([]string{
+					"Some.Custom.Switch",
+					"System.GC.Concurrent",
+					"System.GC.HeapHardLimit",
+					"System.GC.Server",
+					"System.Runtime.TieredPGO",
 				}))
+
+				gcServer, ok := properties.Bool(dotnetexecute.ConfigPropertyGCServer)
+				Expect(ok).To(BeTrue())
+				Expect(gcServer).To(BeTrue())
+
+				gcConcurrent, ok := properties.Bool(dotnetexecute.ConfigPropertyGCConcurrent)
+				Expect(ok).To(BeTrue())
+				Expect(gcConcurrent).To(BeFalse())
+
+				_, ok = properties.Bool(dotnetexecute.ConfigPropertyTieredPGO)
+				Expect(ok).To(BeFalse())
+
+				_, ok = properties.Bool(dotnetexecute.ConfigPropertyInvariantGlobalization)
+				Expect(ok).To(BeFalse())
+
+				heapHardLimit, ok := properties.Int("System.GC.HeapHardLimit")
+				Expect(ok).To(BeTrue())
+				Expect(heapHardLimit).To(Equal(int64(209715200)))
+
+				value, ok := properties.String("Some.Custom.Switch")
+				Expect(ok).To(BeTrue())
+				Expect(value).To(Equal("some-value"))
+
+				value, ok = properties.String("System.GC.HeapHardLimit")
+				Expect(ok).To(BeTrue())
+				Expect(value).To(Equal("209715200"))
 			})
 		})
 
 		context("when the app includes an executable", func() {
 			it.Before(func() {
-				Expect(os.WriteFile(filepath.Join(workingDir, "some-app"), nil, 0700)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, true), 0700)).To(Succeed())
 			})
 
-			it("reports that the app includes an executable", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+			it("reports that the app includes an executable and its architecture", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.Executable).To(BeTrue())
+				Expect(config.Arch).To(Equal("amd64"))
+			})
+
+			context("when the executable has lost its exec bit", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-app"), elfExecutable(elf.EM_AARCH64, elf.ET_EXEC, false), 0600)).To(Succeed())
+				})
+
+				it("still reports that the app includes an executable", func() {
+					configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(configs).To(HaveLen(1))
+
+					config := configs[0]
+					Expect(config.Executable).To(BeTrue())
+					Expect(config.Arch).To(Equal("arm64"))
+				})
+			})
+		})
+
+		context("when the file next to the runtimeconfig.json is not an ELF executable", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app"), []byte("#!/bin/sh\necho some-app\n"), 0700)).To(Succeed())
+			})
+
+			it("reports that the app does not include an executable, whatever its mode", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+				Expect(config.Executable).To(BeFalse())
+				Expect(config.Arch).To(BeEmpty())
+			})
+		})
+
+		context("when the file next to the runtimeconfig.json is a shared library", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app"), elfExecutable(elf.EM_X86_64, elf.ET_DYN, false), 0700)).To(Succeed())
+			})
+
+			it("reports that the app does not include an executable", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+				Expect(config.Executable).To(BeFalse())
 			})
 		})
 
 		context("when the app does not include an executable", func() {
 			it("reports that the app does not include an executable", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.Executable).To(BeFalse())
 			})
 		})
@@ -104,8 +259,11 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the runtime version", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.RuntimeVersion).To(Equal("2.1.3"))
 			})
 		})
@@ -125,8 +283,11 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the runtime version", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.RuntimeVersion).To(Equal("2.1.3"))
 			})
 		})
@@ -150,8 +311,11 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the runtime and ASPNET versions", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.RuntimeVersion).To(Equal("2.1.3"))
 				Expect(config.ASPNETVersion).To(Equal("2.1.4"))
 			})
@@ -169,8 +333,11 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns that version", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.RuntimeVersion).To(Equal("*"))
 			})
 		})
@@ -188,20 +355,100 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("sets runtime and ASP.NET versions to the AspNetCore.App version", func() {
-				config, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
 				Expect(config.RuntimeVersion).To(Equal("2.1.0"))
 				Expect(config.ASPNETVersion).To(Equal("2.1.0"))
 			})
 		})
 
+		context("when the runtimeconfig.json enables globalization invariant mode", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`{
+					"runtimeOptions": {
+						"configProperties": {
+							"System.Globalization.Invariant": true
+						}
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("reports that globalization invariant mode is enabled", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+
+				invariant, ok := config.ConfigProperties.Bool(dotnetexecute.ConfigPropertyInvariantGlobalization)
+				Expect(ok).To(BeTrue())
+				Expect(invariant).To(BeTrue())
+			})
+		})
+
+		context("when the runtimeconfig.json specifies a rollForward policy", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`{
+					"runtimeOptions": {
+						"rollForward": "latestMajor",
+						"framework": {
+							"name": "Microsoft.NETCore.App",
+							"version": "8.0.0"
+						}
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("returns the policy", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+				Expect(config.RollForward).To(Equal(dotnetexecute.RollForwardLatestMajor))
+			})
+
+			context("when DOTNET_ROLL_FORWARD is also set", func() {
+				it.Before(func() {
+					t.Setenv("DOTNET_ROLL_FORWARD", "Disable")
+				})
+
+				it("prefers the runtimeconfig.json policy", func() {
+					configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(configs).To(HaveLen(1))
+
+					config := configs[0]
+					Expect(config.RollForward).To(Equal(dotnetexecute.RollForwardLatestMajor))
+				})
+			})
+		})
+
+		context("when DOTNET_ROLL_FORWARD is set", func() {
+			it.Before(func() {
+				t.Setenv("DOTNET_ROLL_FORWARD", "LATESTPATCH")
+			})
+
+			it("returns the policy", func() {
+				configs, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configs).To(HaveLen(1))
+
+				config := configs[0]
+				Expect(config.RollForward).To(Equal(dotnetexecute.RollForwardLatestPatch))
+			})
+		})
+
 		context("the runtimeconfig.json does not exist", func() {
 			it.Before(func() {
 				Expect(os.RemoveAll(filepath.Join(workingDir, "some-app.runtimeconfig.json"))).NotTo(HaveOccurred())
 			})
 
 			it("returns the os.ErrNotExist", func() {
-				_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+				_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 				Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
 			})
 		})
@@ -209,7 +456,7 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 		context("failure cases", func() {
 			context("when given an invalid glob", func() {
 				it("returns an error", func() {
-					_, err := parser.Parse("[-]")
+					_, err := parser.ParseAll("[-]")
 					Expect(err).To(MatchError(`failed to find *.runtimeconfig.json: syntax error in pattern: "[-]"`))
 				})
 			})
@@ -234,7 +481,7 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+						_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 						Expect(err).To(MatchError(ContainSubstring("malformed runtimeconfig.json: multiple 'Microsoft.AspNetCore.App' frameworks specified")))
 					})
 				})
@@ -258,7 +505,7 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+						_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 						Expect(err).To(MatchError(ContainSubstring("malformed runtimeconfig.json: multiple 'Microsoft.NETCore.App' frameworks specified")))
 					})
 				})
@@ -281,7 +528,7 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+						_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 						Expect(err).To(MatchError(ContainSubstring("malformed runtimeconfig.json: multiple 'Microsoft.AspNetCore.App' frameworks specified")))
 					})
 				})
@@ -305,22 +552,24 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+						_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 						Expect(err).To(MatchError(ContainSubstring("malformed runtimeconfig.json: multiple 'Microsoft.NETCore.App' frameworks specified")))
 					})
 				})
 			})
 
-			context("when there are multiple runtimeconfig.json files", func() {
+			context("the rollForward policy is not supported", func() {
 				it.Before(func() {
-					Expect(os.WriteFile(filepath.Join(workingDir, "other-app.runtimeconfig.json"), []byte(`{}`), 0600)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`{
+						"runtimeOptions": {
+							"rollForward": "Sideways"
+						}
+					}`), 0600)).To(Succeed())
 				})
 
 				it("returns an error", func() {
-					_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
-					Expect(err).To(MatchError(ContainSubstring("multiple *.runtimeconfig.json files present")))
-					Expect(err).To(MatchError(ContainSubstring("some-app.runtimeconfig.json")))
-					Expect(err).To(MatchError(ContainSubstring("other-app.runtimeconfig.json")))
+					_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					Expect(err).To(MatchError(`unsupported rollForward value: "Sideways"`))
 				})
 			})
 
@@ -330,21 +579,62 @@ func testRuntimeConfigParser(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 					Expect(err).To(MatchError(ContainSubstring("unterminated regular expression literal")))
 				})
 			})
 
+			context("when one of the runtimeconfig.json files cannot be parsed", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "other-app.runtimeconfig.json"), []byte(`%%%`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					Expect(err).To(MatchError(ContainSubstring("invalid character")))
+				})
+			})
 			context("the runtimeconfig.json file cannot be parsed", func() {
 				it.Before(func() {
 					Expect(os.WriteFile(filepath.Join(workingDir, "some-app.runtimeconfig.json"), []byte(`%%%`), 0600)).To(Succeed())
 				})
 
 				it("returns an error", func() {
-					_, err := parser.Parse(filepath.Join(workingDir, "*.runtimeconfig.json"))
+					_, err := parser.ParseAll(filepath.Join(workingDir, "*.runtimeconfig.json"))
 					Expect(err).To(MatchError(ContainSubstring("invalid character")))
 				})
 			})
 		})
 	})
 }
+
+// elfExecutable returns the headers of a little-endian 64-bit Linux ELF file
+// for the given machine, with a program interpreter if requested, which is
+// all the buildpack inspects of an apphost.
+func elfExecutable(machine elf.Machine, typ elf.Type, interpreter bool) []byte {
+	header := elf.Header64{
+		Type:      uint16(typ),
+		Machine:   uint16(machine),
+		Version:   uint32(elf.EV_CURRENT),
+		Ehsize:    64,
+		Phentsize: 56,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	header.Ident[elf.EI_OSABI] = byte(elf.ELFOSABI_NONE)
+
+	var progs []elf.Prog64
+	if interpreter {
+		progs = append(progs, elf.Prog64{Type: uint32(elf.PT_INTERP)})
+	}
+	header.Phoff = 64
+	header.Phnum = uint16(len(progs))
+
+	buffer := bytes.NewBuffer(nil)
+	_ = binary.Write(buffer, binary.LittleEndian, header)
+	_ = binary.Write(buffer, binary.LittleEndian, progs)
+
+	return buffer.Bytes()
+}
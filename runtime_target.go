@@ -0,0 +1,72 @@
+package dotnetexecute
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// muslStack reports whether the given stack, or the distribution the platform
+// targets through CNB_TARGET_DISTRO_NAME, uses musl rather than glibc as its C
+// library. The Paketo stacks all use glibc.
+func muslStack(stack string) bool {
+	for _, name := range []string{stack, os.Getenv("CNB_TARGET_DISTRO_NAME")} {
+		name = strings.ToLower(name)
+		if strings.Contains(name, "alpine") || strings.Contains(name, "musl") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRuntimeTarget returns an error when the app was published for a
+// runtime identifier that cannot run on Linux, and a warning when it was
+// published for musl while the stack uses glibc. Portable apps, whose rid is
+// empty, run anywhere.
+func checkRuntimeTarget(appName, rid, arch string, musl bool) (string, error) {
+	switch {
+	case rid == "":
+		return "", nil
+	case strings.HasPrefix(rid, "win"), strings.HasPrefix(rid, "osx"), strings.HasPrefix(rid, "maccatalyst"):
+		return "", fmt.Errorf("app '%s' was published for %s, which cannot run on Linux: publish the app with --runtime %s, or without --runtime", appName, rid, linuxRID(arch))
+	case strings.HasPrefix(rid, "linux-musl-") && !musl:
+		return fmt.Sprintf("app '%s' was published for %s, but the stack uses glibc: the app may fail to start unless it is published with --runtime %s",
+			appName, rid, strings.Replace(rid, "linux-musl-", "linux-", 1)), nil
+	}
+
+	return "", nil
+}
+
+// targetFrameworkVersion returns the .NET version of the given deps.json
+// runtime target, e.g. 8.0 for .NETCoreApp,Version=v8.0/linux-x64.
+func targetFrameworkVersion(runtimeTarget string) string {
+	name, _, _ := strings.Cut(runtimeTarget, "/")
+	_, version, found := strings.Cut(name, ",Version=v")
+	if !found {
+		return ""
+	}
+
+	return version
+}
+
+// lowestFrameworkVersion returns the lowest of the given .NET versions, or an
+// empty string when any of them is unknown.
+func lowestFrameworkVersion(versions []string) string {
+	var lowest *semver.Version
+	var lowestVersion string
+	for _, version := range versions {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return ""
+		}
+
+		if lowest == nil || v.LessThan(lowest) {
+			lowest, lowestVersion = v, version
+		}
+	}
+
+	return lowestVersion
+}
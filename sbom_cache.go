@@ -0,0 +1,159 @@
+package dotnetexecute
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// formattedSBOM is a packit.SBOMFormatter for an SBOM that has already been
+// formatted, such as the one kept in the SBOM cache layer.
+type formattedSBOM []formattedSBOMFile
+
+type formattedSBOMFile struct {
+	extension string
+	content   []byte
+}
+
+func (f formattedSBOM) Formats() []packit.SBOMFormat {
+	var formats []packit.SBOMFormat
+	for _, file := range f {
+		formats = append(formats, packit.SBOMFormat{
+			Extension: file.extension,
+			Content:   bytes.NewReader(file.content),
+		})
+	}
+
+	return formats
+}
+
+// formatSBOM reads every format of the given formatter into memory.
+func formatSBOM(formatter packit.SBOMFormatter) (formattedSBOM, error) {
+	var files formattedSBOM
+	for _, format := range formatter.Formats() {
+		content, err := io.ReadAll(format.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format SBOM: %w", err)
+		}
+
+		files = append(files, formattedSBOMFile{
+			extension: format.Extension,
+			content:   content,
+		})
+	}
+
+	return files, nil
+}
+
+// writeSBOMCache writes the formatted SBOM into the cache layer at the given
+// path.
+func writeSBOMCache(path string, files formattedSBOM) error {
+	for _, file := range files {
+		err := os.WriteFile(filepath.Join(path, fmt.Sprintf("sbom.%s", file.extension)), file.content, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write SBOM cache: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// readSBOMCache reads the SBOM with the given file extensions, in that order,
+// from the cache layer at the given path.
+func readSBOMCache(path string, extensions []string) (formattedSBOM, error) {
+	var files formattedSBOM
+	for _, extension := range extensions {
+		content, err := os.ReadFile(filepath.Join(path, fmt.Sprintf("sbom.%s", extension)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SBOM cache: %w", err)
+		}
+
+		files = append(files, formattedSBOMFile{
+			extension: extension,
+			content:   content,
+		})
+	}
+
+	return files, nil
+}
+
+// appContentHash returns a hash of the files the SBOM of the app under the
+// given directory is generated from, combined with the given settings that
+// affect it. In deps mode, those are the DLLs, deps.json and
+// runtimeconfig.json files; the other modes catalog every file in the scope.
+func appContentHash(root, mode string, scope SBOMScope, settings ...string) (string, error) {
+	hash := sha256.New()
+	for _, setting := range settings {
+		fmt.Fprintf(hash, "%s\x00", setting)
+	}
+
+	// Missing targets are left to the SBOM generator to report
+	targets, exclude := scope.resolve(root)
+	targets = slices.DeleteFunc(targets, func(target string) bool {
+		_, err := os.Stat(target)
+		return errors.Is(err, os.ErrNotExist)
+	})
+
+	files, err := findAppFiles(root, targets, exclude, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to hash app content: %w", err)
+	}
+
+	// findAppFiles walks the targets in lexical order, so the hash does not
+	// depend on the order in which the filesystem lists them
+	for _, path := range files {
+		name := filepath.Base(path)
+		if mode == SBOMModeDeps && !strings.HasSuffix(name, ".dll") && !strings.HasSuffix(name, ".deps.json") && !strings.HasSuffix(name, ".runtimeconfig.json") {
+			continue
+		}
+
+		err := hashFile(hash, root, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash app content: %w", err)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile adds the path, relative to root, and the content of the given
+// regular file to the hash. Other files, such as symlinks, are skipped.
+func hashFile(hash io.Writer, root, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fileHash := sha256.New()
+	_, err = io.Copy(fileHash, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(hash, "%s\x00%x\x00", rel, fileHash.Sum(nil))
+	return nil
+}
@@ -0,0 +1,434 @@
+package dotnetexecute
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anchore/packageurl-go"
+	"github.com/anchore/syft/syft"
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/linux"
+	"github.com/anchore/syft/syft/pkg"
+	"github.com/anchore/syft/syft/pkg/cataloger"
+	syftsbom "github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// The ways DotnetSBOMGenerator can find the components of an app, selected
+// with BP_DOTNET_SBOM_MODE.
+const (
+	// SBOMModeDeps reads the NuGet packages an app references from its
+	// deps.json files.
+	SBOMModeDeps = "deps"
+
+	// SBOMModeScan scans every file of the app with syft.
+	SBOMModeScan = "scan"
+
+	// SBOMModeBoth scans every file of the app with syft and adds the
+	// components found in its deps.json files.
+	SBOMModeBoth = "both"
+)
+
+// DotnetSBOMGenerator implements SBOMGenerator for .NET apps. In deps mode it
+// only reads the deps.json files of the apps, which is much faster than
+// scanning every file of large publish directories. In every mode, the SBOM
+// lists each app with a runtimeconfig.json file and the shared frameworks it
+// depends on.
+type DotnetSBOMGenerator struct {
+	mode       string
+	depsParser DepsParser
+}
+
+func NewDotnetSBOMGenerator(mode string) DotnetSBOMGenerator {
+	return DotnetSBOMGenerator{
+		mode:       mode,
+		depsParser: NewDepsParser(),
+	}
+}
+
+// Generate returns a SBOM of the apps found in the given directory, limited
+// to the given scope.
+func (g DotnetSBOMGenerator) Generate(path string, scope SBOMScope) (sbom.SBOM, error) {
+	if g.mode != SBOMModeDeps && g.mode != SBOMModeScan && g.mode != SBOMModeBoth {
+		return sbom.SBOM{}, fmt.Errorf("unsupported BP_DOTNET_SBOM_MODE: %q, must be one of %q, %q or %q", g.mode, SBOMModeDeps, SBOMModeScan, SBOMModeBoth)
+	}
+
+	src, err := source.NewFromDirectory(path)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+
+	targets, exclude := scope.resolve(path)
+
+	catalog := pkg.NewCollection()
+	var release *linux.Release
+	if g.mode == SBOMModeScan || g.mode == SBOMModeBoth {
+		for _, target := range targets {
+			packages, targetRelease, err := scanPackages(path, target, exclude)
+			if err != nil {
+				return sbom.SBOM{}, err
+			}
+
+			catalog.Add(packages...)
+			if release == nil {
+				release = targetRelease
+			}
+		}
+	}
+
+	if g.mode == SBOMModeDeps || g.mode == SBOMModeBoth {
+		packages, err := g.depsPackages(path, targets, exclude)
+		if err != nil {
+			return sbom.SBOM{}, err
+		}
+
+		// The scan finds the NuGet packages of the deps.json files as well, so
+		// only add the components it missed
+		scanned := map[string]bool{}
+		for p := range catalog.Enumerate() {
+			scanned[p.PURL] = true
+		}
+
+		for _, p := range packages {
+			if !scanned[p.PURL] {
+				catalog.Add(p)
+			}
+		}
+	}
+
+	packages, relationships, err := g.frameworkPackages(path, targets, exclude)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+	catalog.Add(packages...)
+
+	return sbom.NewSBOM(syftsbom.SBOM{
+		Artifacts: syftsbom.Artifacts{
+			Packages:          catalog,
+			LinuxDistribution: release,
+		},
+		Relationships: relationships,
+		Source:        src.Metadata,
+	}), nil
+}
+
+// resolve returns the absolute paths of the files and directories in the
+// scope of the app at the given path, and the exclude patterns in the form
+// pathExcluded expects.
+func (s SBOMScope) resolve(path string) ([]string, []string) {
+	targets := []string{path}
+	if len(s.Paths) > 0 {
+		targets = nil
+		for _, include := range s.Paths {
+			targets = append(targets, filepath.Join(path, include))
+		}
+	}
+
+	var exclude []string
+	for _, pattern := range s.Exclude {
+		exclude = append(exclude, strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(pattern), "./"), "/"))
+	}
+
+	return targets, exclude
+}
+
+// scanPackages scans the given target, a file or directory under root, with
+// syft and returns the packages it finds outside of the excluded paths.
+func scanPackages(root, target string, exclude []string) ([]pkg.Package, *linux.Release, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan %s: %w", target, err)
+	}
+
+	var src source.Source
+	if info.IsDir() {
+		src, err = source.NewFromDirectory(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		src.Exclusions = scanExclusions(root, target, exclude)
+	} else {
+		var cleanup func()
+		src, cleanup = source.NewFromFile(target)
+		defer cleanup()
+	}
+
+	catalog, _, release, err := syft.CatalogPackages(&src, cataloger.Config{
+		Search: cataloger.SearchConfig{
+			Scope: source.UnknownScope,
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var packages []pkg.Package
+	for _, p := range catalog.Sorted() {
+		excluded := len(exclude) > 0
+		for _, location := range p.Locations.ToSlice() {
+			rel, err := filepath.Rel(root, filepath.Join(target, location.RealPath))
+			if err != nil || !pathExcluded(rel, exclude) {
+				excluded = false
+				break
+			}
+		}
+
+		if !excluded {
+			packages = append(packages, p)
+		}
+	}
+
+	return packages, release, nil
+}
+
+// scanExclusions translates the exclude patterns, relative to root, into the
+// patterns syft skips while scanning the target directory. Patterns that
+// cannot be expressed relative to the target are left to the filtering of the
+// scan results.
+func scanExclusions(root, target string, exclude []string) []string {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+
+	var exclusions []string
+	for _, pattern := range exclude {
+		switch {
+		case strings.HasPrefix(pattern, "**/"):
+			exclusions = append(exclusions, pattern)
+		case rel == ".":
+			exclusions = append(exclusions, fmt.Sprintf("./%s", pattern))
+		case strings.HasPrefix(pattern, rel+"/"):
+			exclusions = append(exclusions, fmt.Sprintf("./%s", strings.TrimPrefix(pattern, rel+"/")))
+		}
+	}
+
+	return exclusions
+}
+
+// pathExcluded returns whether the given path, relative to the app directory,
+// or one of its parent directories matches one of the exclude patterns.
+func pathExcluded(path string, exclude []string) bool {
+	for path = filepath.ToSlash(path); path != "." && path != "/" && path != ""; path = filepath.ToSlash(filepath.Dir(path)) {
+		for _, pattern := range exclude {
+			if matched, _ := doublestar.Match(pattern, path); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// findAppFiles returns the files with the given suffix in the given targets
+// under root, skipping the excluded paths.
+func findAppFiles(root string, targets, exclude []string, suffix string) ([]string, error) {
+	var files []string
+	for _, target := range targets {
+		err := filepath.WalkDir(target, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			if pathExcluded(rel, exclude) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find *%s: %w", suffix, err)
+		}
+	}
+
+	return files, nil
+}
+
+// depsPackages returns the NuGet packages referenced by the deps.json files
+// found in the given targets under root, skipping the excluded paths.
+func (g DotnetSBOMGenerator) depsPackages(root string, targets, exclude []string) ([]pkg.Package, error) {
+	depsFiles, err := findAppFiles(root, targets, exclude, ".deps.json")
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []pkg.Package
+	for _, path := range depsFiles {
+		deps, err := g.depsParser.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		location, err := sbomLocation(root, path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, library := range deps.Libraries {
+			// Project libraries are the app and the projects it references,
+			// which are not distributed as NuGet packages
+			if library.Type != DepsLibraryTypePackage && library.Type != DepsLibraryTypeRuntimePack {
+				continue
+			}
+
+			packages = append(packages, nugetPackage(library.Name, library.Version, location, &pkg.DotnetDepsMetadata{
+				Name:     library.Name,
+				Version:  library.Version,
+				Path:     library.Path,
+				Sha512:   library.SHA512,
+				HashPath: library.HashPath,
+			}))
+		}
+	}
+
+	return packages, nil
+}
+
+// frameworkPackages returns a package for each app with a runtimeconfig.json
+// file in the given targets under root, a package for each shared framework
+// those apps reference, and the relationships recording which framework each
+// app depends on.
+func (g DotnetSBOMGenerator) frameworkPackages(root string, targets, exclude []string) ([]pkg.Package, []artifact.Relationship, error) {
+	runtimeConfigFiles, err := findAppFiles(root, targets, exclude, ".runtimeconfig.json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type frameworkComponent struct {
+		pkg.Package
+		apps []pkg.Package
+	}
+
+	var apps []pkg.Package
+	var frameworks []*frameworkComponent
+	for _, path := range runtimeConfigFiles {
+		runtimeConfig, err := parseRuntimeConfig(path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		location, err := sbomLocation(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		app := pkg.Package{
+			Name:      runtimeConfig.AppName,
+			Version:   g.appVersion(path, runtimeConfig.AppName),
+			Locations: source.NewLocationSet(location),
+			Language:  pkg.Dotnet,
+			Type:      pkg.DotnetPkg,
+		}
+		app.SetID()
+		apps = append(apps, app)
+
+		references := []struct{ name, version string }{
+			{"Microsoft.NETCore.App", runtimeConfig.RuntimeVersion},
+			{"Microsoft.AspNetCore.App", runtimeConfig.ASPNETVersion},
+		}
+
+		for _, reference := range references {
+			if reference.version == "" || reference.version == "*" {
+				continue
+			}
+
+			// Apps sharing the same framework version share its component
+			var shared *frameworkComponent
+			for _, f := range frameworks {
+				if f.Name == reference.name && f.Version == reference.version {
+					shared = f
+				}
+			}
+
+			if shared == nil {
+				shared = &frameworkComponent{Package: nugetPackage(reference.name, reference.version, location, nil)}
+				frameworks = append(frameworks, shared)
+			} else {
+				shared.Locations.Add(location)
+				shared.SetID()
+			}
+
+			shared.apps = append(shared.apps, app)
+		}
+	}
+
+	packages := apps
+	var relationships []artifact.Relationship
+	for _, f := range frameworks {
+		packages = append(packages, f.Package)
+
+		for _, app := range f.apps {
+			relationships = append(relationships, artifact.Relationship{
+				From: f.Package,
+				To:   app,
+				Type: artifact.DependencyOfRelationship,
+			})
+		}
+	}
+
+	return packages, relationships, nil
+}
+
+// appVersion returns the version of the app recorded in the deps.json file
+// next to its runtimeconfig.json file, if any.
+func (g DotnetSBOMGenerator) appVersion(runtimeConfigPath, appName string) string {
+	deps, err := g.depsParser.Parse(fmt.Sprintf("%s.deps.json", strings.TrimSuffix(runtimeConfigPath, ".runtimeconfig.json")))
+	if err != nil {
+		return ""
+	}
+
+	for _, library := range deps.Libraries {
+		if library.Type == DepsLibraryTypeProject && library.Name == appName {
+			return library.Version
+		}
+	}
+
+	return ""
+}
+
+func nugetPackage(name, version string, location source.Location, metadata *pkg.DotnetDepsMetadata) pkg.Package {
+	p := pkg.Package{
+		Name:      name,
+		Version:   version,
+		Locations: source.NewLocationSet(location),
+		Language:  pkg.Dotnet,
+		Type:      pkg.DotnetPkg,
+		PURL:      packageurl.NewPackageURL(packageurl.TypeNuget, "", name, version, nil, "").ToString(),
+	}
+
+	if metadata != nil {
+		p.MetadataType = pkg.DotnetDepsMetadataType
+		p.Metadata = *metadata
+	}
+
+	p.SetID()
+
+	return p
+}
+
+func sbomLocation(root, path string) (source.Location, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return source.Location{}, err
+	}
+
+	return source.NewLocation(filepath.Join("/", rel)), nil
+}
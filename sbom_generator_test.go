@@ -0,0 +1,360 @@
+package dotnetexecute_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	dotnetexecute "github.com/paketo-buildpacks/dotnet-execute"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+)
+
+func testSBOMGenerator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workingDir string
+	)
+
+	type artifact struct {
+		Name     string            `json:"name"`
+		Version  string            `json:"version"`
+		PURL     string            `json:"purl"`
+		Metadata map[string]string `json:"metadata"`
+	}
+
+	artifacts := func(bom sbom.SBOM) []artifact {
+		var document struct {
+			Artifacts []artifact `json:"artifacts"`
+		}
+		Expect(json.NewDecoder(sbom.NewFormattedReader(bom, sbom.SyftFormat)).Decode(&document)).To(Succeed())
+
+		return document.Artifacts
+	}
+
+	it.Before(func() {
+		var err error
+		workingDir, err = os.MkdirTemp("", "working-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`{
+  "libraries": {
+    "MyApp/1.0.0": {
+      "type": "project",
+      "serviceable": false,
+      "sha512": ""
+    },
+    "Newtonsoft.Json/13.0.3": {
+      "type": "package",
+      "serviceable": true,
+      "sha512": "sha512-HrC5BXdl00IP9zeV+0Z848QWPAoCr9P3bDEZguI+gkLcBKAOxix/tLEAAHC+UvDNPv4a2d18lOReHMOagPa+zQ==",
+      "path": "newtonsoft.json/13.0.3",
+      "hashPath": "newtonsoft.json.13.0.3.nupkg.sha512"
+    }
+  }
+}`), 0600)).To(Succeed())
+
+		Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.runtimeconfig.json"), []byte(`{
+  "runtimeOptions": {
+    "frameworks": [
+      { "name": "Microsoft.NETCore.App", "version": "8.0.2" },
+      { "name": "Microsoft.AspNetCore.App", "version": "8.0.2" }
+    ]
+  }
+}`), 0600)).To(Succeed())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workingDir)).To(Succeed())
+	})
+
+	context("in deps mode", func() {
+		it("returns the NuGet packages and shared frameworks the app references", func() {
+			bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeDeps).Generate(workingDir, dotnetexecute.SBOMScope{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(artifacts(bom)).To(ConsistOf(
+				artifact{
+					Name:    "Newtonsoft.Json",
+					Version: "13.0.3",
+					PURL:    "pkg:nuget/Newtonsoft.Json@13.0.3",
+					Metadata: map[string]string{
+						"name":     "Newtonsoft.Json",
+						"version":  "13.0.3",
+						"path":     "newtonsoft.json/13.0.3",
+						"sha512":   "sha512-HrC5BXdl00IP9zeV+0Z848QWPAoCr9P3bDEZguI+gkLcBKAOxix/tLEAAHC+UvDNPv4a2d18lOReHMOagPa+zQ==",
+						"hashPath": "newtonsoft.json.13.0.3.nupkg.sha512",
+					},
+				},
+				artifact{
+					Name:    "MyApp",
+					Version: "1.0.0",
+				},
+				artifact{
+					Name:    "Microsoft.NETCore.App",
+					Version: "8.0.2",
+					PURL:    "pkg:nuget/Microsoft.NETCore.App@8.0.2",
+				},
+				artifact{
+					Name:    "Microsoft.AspNetCore.App",
+					Version: "8.0.2",
+					PURL:    "pkg:nuget/Microsoft.AspNetCore.App@8.0.2",
+				},
+			))
+		})
+
+		context("when a deps.json file is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "MyApp.deps.json"), []byte(`%%%`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeDeps).Generate(workingDir, dotnetexecute.SBOMScope{})
+				Expect(err).To(MatchError(ContainSubstring("failed to parse")))
+			})
+		})
+	})
+
+	context("in scan mode", func() {
+		it("returns the packages found by scanning the app", func() {
+			bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{})
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, a := range artifacts(bom) {
+				names = append(names, a.Name)
+			}
+			Expect(names).To(ConsistOf("Newtonsoft.Json", "MyApp", "Microsoft.NETCore.App", "Microsoft.AspNetCore.App"))
+		})
+	})
+
+	context("in both mode", func() {
+		it("adds the components the scan missed", func() {
+			bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeBoth).Generate(workingDir, dotnetexecute.SBOMScope{})
+			Expect(err).NotTo(HaveOccurred())
+
+			var purls []string
+			for _, a := range artifacts(bom) {
+				purls = append(purls, a.PURL)
+			}
+			Expect(purls).To(ConsistOf(
+				"pkg:nuget/Newtonsoft.Json@13.0.3",
+				"",
+				"pkg:nuget/Microsoft.NETCore.App@8.0.2",
+				"pkg:nuget/Microsoft.AspNetCore.App@8.0.2",
+			))
+		})
+	})
+
+	context("when the app references shared frameworks", func() {
+		var bom sbom.SBOM
+
+		it.Before(func() {
+			var err error
+			bom, err = dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("records that the app depends on them in CycloneDX", func() {
+			// The CycloneDX components do not carry a bom-ref, so map the
+			// references through the package IDs of the Syft JSON document
+			var syftDocument struct {
+				Artifacts []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"artifacts"`
+			}
+			Expect(json.NewDecoder(sbom.NewFormattedReader(bom, sbom.SyftFormat)).Decode(&syftDocument)).To(Succeed())
+
+			names := map[string]string{}
+			for _, a := range syftDocument.Artifacts {
+				names[a.ID] = a.Name
+			}
+
+			var document struct {
+				Dependencies []struct {
+					Ref       string   `json:"ref"`
+					DependsOn []string `json:"dependsOn"`
+				} `json:"dependencies"`
+			}
+			Expect(json.NewDecoder(sbom.NewFormattedReader(bom, sbom.CycloneDXFormat)).Decode(&document)).To(Succeed())
+
+			var dependencies []string
+			for _, dependency := range document.Dependencies {
+				for _, ref := range dependency.DependsOn {
+					dependencies = append(dependencies, names[dependency.Ref]+" -> "+names[ref])
+				}
+			}
+			Expect(dependencies).To(ConsistOf(
+				"MyApp -> Microsoft.NETCore.App",
+				"MyApp -> Microsoft.AspNetCore.App",
+			))
+		})
+
+		it("records that they are dependencies of the app in SPDX", func() {
+			var document struct {
+				Packages []struct {
+					SPDXID string `json:"SPDXID"`
+					Name   string `json:"name"`
+				} `json:"packages"`
+				Relationships []struct {
+					Element string `json:"spdxElementId"`
+					Related string `json:"relatedSpdxElement"`
+					Type    string `json:"relationshipType"`
+				} `json:"relationships"`
+			}
+			Expect(json.NewDecoder(sbom.NewFormattedReader(bom, sbom.SPDXFormat)).Decode(&document)).To(Succeed())
+
+			ids := map[string]string{}
+			for _, p := range document.Packages {
+				ids[p.SPDXID] = p.Name
+			}
+
+			var relationships []string
+			for _, relationship := range document.Relationships {
+				if relationship.Type == "DEPENDENCY_OF" {
+					relationships = append(relationships, ids[relationship.Element]+" -> "+ids[relationship.Related])
+				}
+			}
+			Expect(relationships).To(ConsistOf(
+				"Microsoft.NETCore.App -> MyApp",
+				"Microsoft.AspNetCore.App -> MyApp",
+			))
+		})
+
+		it("records that they are dependencies of the app in Syft JSON", func() {
+			var document struct {
+				Artifacts []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"artifacts"`
+				Relationships []struct {
+					Parent string `json:"parent"`
+					Child  string `json:"child"`
+					Type   string `json:"type"`
+				} `json:"artifactRelationships"`
+			}
+			Expect(json.NewDecoder(sbom.NewFormattedReader(bom, sbom.SyftFormat)).Decode(&document)).To(Succeed())
+
+			ids := map[string]string{}
+			for _, a := range document.Artifacts {
+				ids[a.ID] = a.Name
+			}
+
+			var relationships []string
+			for _, relationship := range document.Relationships {
+				if relationship.Type == "dependency-of" {
+					relationships = append(relationships, ids[relationship.Parent]+" -> "+ids[relationship.Child])
+				}
+			}
+			Expect(relationships).To(ConsistOf(
+				"Microsoft.NETCore.App -> MyApp",
+				"Microsoft.AspNetCore.App -> MyApp",
+			))
+		})
+	})
+
+	context("with a scope", func() {
+		it.Before(func() {
+			Expect(os.MkdirAll(filepath.Join(workingDir, "wwwroot", "fixtures"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "wwwroot", "fixtures", "Fixture.deps.json"), []byte(`{
+  "libraries": {
+    "Fixture.Package/1.0.0": {
+      "type": "package",
+      "serviceable": true,
+      "sha512": "sha512-some-hash",
+      "path": "fixture.package/1.0.0",
+      "hashPath": "fixture.package.1.0.0.nupkg.sha512"
+    }
+  }
+}`), 0600)).To(Succeed())
+		})
+
+		names := func(bom sbom.SBOM) []string {
+			var names []string
+			for _, a := range artifacts(bom) {
+				names = append(names, a.Name)
+			}
+			return names
+		}
+
+		context("in deps mode", func() {
+			it("leaves out the excluded paths", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeDeps).Generate(workingDir, dotnetexecute.SBOMScope{
+					Exclude: []string{"wwwroot"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Newtonsoft.Json", "MyApp", "Microsoft.NETCore.App", "Microsoft.AspNetCore.App"))
+			})
+
+			it("only includes the given paths", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeDeps).Generate(workingDir, dotnetexecute.SBOMScope{
+					Paths: []string{"MyApp.deps.json"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Newtonsoft.Json"))
+			})
+		})
+
+		context("in scan mode", func() {
+			it("finds everything without a scope", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Newtonsoft.Json", "Fixture.Package", "MyApp", "Microsoft.NETCore.App", "Microsoft.AspNetCore.App"))
+			})
+
+			it("leaves out the directories matching an exclude pattern", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{
+					Exclude: []string{"**/fixtures"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Newtonsoft.Json", "MyApp", "Microsoft.NETCore.App", "Microsoft.AspNetCore.App"))
+			})
+
+			it("leaves out the files matching an exclude pattern", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{
+					Exclude: []string{"./wwwroot/*/*.deps.json"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Newtonsoft.Json", "MyApp", "Microsoft.NETCore.App", "Microsoft.AspNetCore.App"))
+			})
+
+			it("only scans the given paths", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{
+					Paths: []string{"wwwroot"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(ConsistOf("Fixture.Package"))
+			})
+
+			it("leaves out the excluded paths inside the given paths", func() {
+				bom, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{
+					Paths:   []string{"wwwroot"},
+					Exclude: []string{"*/fixtures"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(names(bom)).To(BeEmpty())
+			})
+
+			context("when an included path does not exist", func() {
+				it("returns an error", func() {
+					_, err := dotnetexecute.NewDotnetSBOMGenerator(dotnetexecute.SBOMModeScan).Generate(workingDir, dotnetexecute.SBOMScope{
+						Paths: []string{"missing"},
+					})
+					Expect(err).To(MatchError(ContainSubstring("failed to scan")))
+					Expect(err).To(MatchError(os.ErrNotExist))
+				})
+			})
+		})
+	})
+
+	context("with an unsupported mode", func() {
+		it("returns an error", func() {
+			_, err := dotnetexecute.NewDotnetSBOMGenerator("everything").Generate(workingDir, dotnetexecute.SBOMScope{})
+			Expect(err).To(MatchError(`unsupported BP_DOTNET_SBOM_MODE: "everything", must be one of "deps", "scan" or "both"`))
+		})
+	})
+}
@@ -0,0 +1,97 @@
+package dotnetexecute
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseShellWords splits the given string into words following the quoting
+// rules of a POSIX shell: words are separated by unquoted whitespace, single
+// quotes preserve their contents literally, double quotes preserve their
+// contents except for backslash escapes of `"`, `\`, `$` and "`", and an
+// unquoted backslash escapes the next character. No expansion is performed.
+func parseShellWords(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("unexpected end of input after backslash")
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quoted string")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+// shellQuote joins the given words into a string that a POSIX shell splits
+// back into the same words. Words that contain characters the shell would
+// interpret are wrapped in single quotes.
+func shellQuote(words ...string) string {
+	var quoted []string
+	for _, word := range words {
+		if word != "" && strings.Trim(word, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-") == "" {
+			quoted = append(quoted, word)
+			continue
+		}
+
+		quoted = append(quoted, fmt.Sprintf("'%s'", strings.ReplaceAll(word, "'", `'"'"'`)))
+	}
+
+	return strings.Join(quoted, " ")
+}
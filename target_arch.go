@@ -0,0 +1,121 @@
+package dotnetexecute
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ridArchs maps the architecture part of a .NET runtime identifier (RID) to
+// its GOARCH name. See https://learn.microsoft.com/en-us/dotnet/core/rid-catalog.
+var ridArchs = map[string]string{
+	"x64":         "amd64",
+	"arm64":       "arm64",
+	"arm":         "arm",
+	"x86":         "386",
+	"riscv64":     "riscv64",
+	"ppc64le":     "ppc64le",
+	"s390x":       "s390x",
+	"loongarch64": "loong64",
+}
+
+// targetArch returns the architecture of the image being built, using GOARCH
+// names. It is given by the platform through CNB_TARGET_ARCH, and defaults to
+// the architecture the buildpack runs on.
+func targetArch() string {
+	if arch := os.Getenv("CNB_TARGET_ARCH"); arch != "" {
+		return arch
+	}
+
+	return runtime.GOARCH
+}
+
+// linuxRID returns the Linux runtime identifier of the given architecture.
+func linuxRID(arch string) string {
+	for ridArch, goArch := range ridArchs {
+		if goArch == arch {
+			return fmt.Sprintf("linux-%s", ridArch)
+		}
+	}
+
+	return fmt.Sprintf("linux-%s", arch)
+}
+
+// checkAppArch returns an error when the apphost of one of the given apps, or
+// the native libraries under the runtimes directory of the app root, cannot
+// run on the given architecture. When the only native libraries for that
+// architecture were published for musl while the stack uses glibc, it returns
+// a warning instead, as does checkRuntimeTarget for apps published for musl.
+func checkAppArch(appRoot string, runtimeConfigs []RuntimeConfig, arch string, musl bool) (string, error) {
+	for _, runtimeConfig := range runtimeConfigs {
+		if runtimeConfig.Executable && runtimeConfig.Arch != "" && runtimeConfig.Arch != arch {
+			return "", fmt.Errorf("the apphost of app '%s' was published for %s, but the image is built for %s: publish the app with --runtime %s, or build the image for %s",
+				runtimeConfig.AppName, linuxRID(runtimeConfig.Arch), linuxRID(arch), linuxRID(arch), runtimeConfig.Arch)
+		}
+	}
+
+	libraries, err := filepath.Glob(filepath.Join(appRoot, "runtimes", "*", "native", "*"))
+	if err != nil {
+		// not tested
+		return "", err
+	}
+
+	// Portable apps carry native libraries for several RIDs, of which the host
+	// picks the one matching the machine, so only the absence of a matching
+	// one is a problem. The host of a glibc stack never loads the libraries
+	// published for musl, but that of a musl stack falls back to the glibc
+	// ones.
+	found := map[string]bool{}
+	matched := false
+	muslMatched := false
+	for _, path := range libraries {
+		rid := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		if !strings.HasPrefix(rid, "linux-") {
+			continue
+		}
+
+		info, ok, err := inspectELF(path)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+
+		ridArch := ridArchs[rid[strings.LastIndex(rid, "-")+1:]]
+		if ridArch == arch && info.Arch != arch {
+			return "", fmt.Errorf("native library %s is built for %s, but is shipped for %s, which the image is built for: check the NuGet package that provides it",
+				filepath.Join("runtimes", rid, "native", filepath.Base(path)), info.Arch, rid)
+		}
+
+		if info.Arch == arch {
+			if musl || !strings.HasPrefix(rid, "linux-musl-") {
+				matched = true
+			} else {
+				muslMatched = true
+			}
+		}
+		found[rid] = true
+	}
+
+	if len(found) == 0 || matched {
+		return "", nil
+	}
+
+	var rids []string
+	for rid := range found {
+		rids = append(rids, rid)
+	}
+	sort.Strings(rids)
+
+	if muslMatched {
+		return fmt.Sprintf("the native libraries of the app were only published for %s, but the stack uses glibc: the app may fail to start unless it is published with --runtime %s",
+			strings.Join(rids, ", "), linuxRID(arch)), nil
+	}
+
+	return "", fmt.Errorf("the native libraries of the app were only published for %s, but the image is built for %s: publish the app with --runtime %s",
+		strings.Join(rids, ", "), linuxRID(arch), linuxRID(arch))
+}